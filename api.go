@@ -1,6 +1,8 @@
 package pluggable
 
 import (
+	"reflect"
+
 	"github.com/moisespsena-go/logging"
 )
 
@@ -103,3 +105,27 @@ type OptionProvider interface {
 type OptionProviderE interface {
 	ProvidesOptions(options *Options) (err error)
 }
+
+type PluginReconfigure interface {
+	Reconfigure(changed []string) error
+}
+
+// LazyOptionProvider provides options computed on first access.
+type LazyOptionProvider interface {
+	LazyOptions() map[string]LazyOption
+}
+
+// PluginProvideServices declares the services types provided by plugin. Use ServiceType to get it.
+type PluginProvideServices interface {
+	ProvideServices() []reflect.Type
+}
+
+// PluginRequireServices declares the services types required by plugin. The plugin initializes
+// after the providers.
+type PluginRequireServices interface {
+	RequireServices() []reflect.Type
+}
+
+type PluginStop interface {
+	Stop() error
+}
@@ -11,7 +11,11 @@ import (
 	"github.com/moisespsena-go/path-helpers"
 )
 
-var E_FS = PKG + ".FS"
+var (
+	E_FS = PKG + ".FS"
+	// H_FS is the handler ID of plugin FS registration
+	H_FS = PKG + ".FS"
+)
 
 type PluginFSInterface interface {
 	PluginEventDispatcherInterface
@@ -51,7 +55,7 @@ func (p *PluginsFS) PluginPrivateFS(pluginUID string) assetfsapi.Interface {
 }
 
 func InitPluginFS(pls PluginFSInterface) {
-	pls.OnPlugin("register", func(e PluginEventInterface) (err error) {
+	pls.OnPluginWithOptions("register", func(e PluginEventInterface) (err error) {
 		register := pls.Dispatcher().(PluginFSInterface).AssetFSPathRegister()
 		p := e.Plugin()
 		pfs := pls.PluginPrivateFS(p.UID())
@@ -90,7 +94,7 @@ func InitPluginFS(pls PluginFSInterface) {
 		}
 
 		return nil
-	})
+	}, HandlerID(H_FS))
 }
 
 func NewPluginsFS(fs assetfsapi.Interface) *PluginsFS {
@@ -136,9 +140,15 @@ func (a *FSEvent) RegisterAssetPath(basePath string) {
 	}
 }
 
+// OnFS registers the callback of E_FS event. Events of other type are returned as error.
 func OnFS(p EventDispatcherInterface, cb func(e *FSEvent)) {
-	p.On(E_FS, func(e PluginEventInterface) {
-		cb(e.(*FSEvent))
+	p.On(E_FS, func(e PluginEventInterface) error {
+		fe, ok := e.(*FSEvent)
+		if !ok {
+			return eventTypeMismatch(E_FS, e, fe)
+		}
+		cb(fe)
+		return nil
 	})
 }
 
@@ -0,0 +1,158 @@
+package pluggable
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// DefaultAsyncQueueSize is the default per-plugin async queue size.
+var DefaultAsyncQueueSize = 64
+
+// EventFactory creates a new event for each async delivery, because the event is modified by handlers.
+type EventFactory func() EventInterface
+
+// Future is the result of async trigger.
+type Future struct {
+	wg   sync.WaitGroup
+	mu   sync.Mutex
+	errs []error
+	done chan struct{}
+}
+
+func newFuture(n int) *Future {
+	f := &Future{done: make(chan struct{})}
+	f.wg.Add(n)
+	go func() {
+		f.wg.Wait()
+		close(f.done)
+	}()
+	return f
+}
+
+func (f *Future) complete(err error) {
+	if err != nil {
+		f.mu.Lock()
+		f.errs = append(f.errs, err)
+		f.mu.Unlock()
+	}
+	f.wg.Done()
+}
+
+// Done is closed when all deliveries completes.
+func (f *Future) Done() <-chan struct{} {
+	return f.done
+}
+
+// Wait waits all deliveries and returns the joined handlers errors.
+func (f *Future) Wait() error {
+	<-f.done
+	return errors.Join(f.Errors()...)
+}
+
+// WaitContext is like Wait, but returns ctx error if it done before.
+func (f *Future) WaitContext(ctx context.Context) error {
+	select {
+	case <-f.done:
+		return f.Wait()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Errors returns the handlers errors received until now.
+func (f *Future) Errors() []error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]error{}, f.errs...)
+}
+
+// SetAsyncQueueSize sets the size of per-plugin async queues created after it. When the queue is
+// full, the async trigger fails with AsyncFull.
+func (ped *PluginEventDispatcher) SetAsyncQueueSize(size int) {
+	ped.asyncQueueSize = size
+}
+
+// asyncSend sends the task to the queue of key, without block. Returns AsyncClosed after CloseAsync
+// and AsyncFull if the queue is full.
+func (ped *PluginEventDispatcher) asyncSend(key string, task func()) error {
+	ped.asyncMu.Lock()
+	defer ped.asyncMu.Unlock()
+	if ped.asyncClosed {
+		return AsyncClosed
+	}
+	q, ok := ped.asyncQueues[key]
+	if !ok {
+		if ped.asyncQueues == nil {
+			ped.asyncQueues = map[string]chan func(){}
+		}
+		size := ped.asyncQueueSize
+		if size <= 0 {
+			size = DefaultAsyncQueueSize
+		}
+		q = make(chan func(), size)
+		ped.asyncQueues[key] = q
+		ped.asyncWorkers.Add(1)
+		go func() {
+			defer ped.asyncWorkers.Done()
+			for task := range q {
+				task()
+			}
+		}()
+	}
+	select {
+	case q <- task:
+		return nil
+	default:
+		return AsyncFull
+	}
+}
+
+// TriggerAsync triggers the event created by newEvent on this dispatcher asynchronously. The async
+// events of dispatcher are delivered in order.
+func (ped *PluginEventDispatcher) TriggerAsync(newEvent EventFactory) *Future {
+	f := newFuture(1)
+	dis := ped.PluginDispatcher()
+	if err := ped.asyncSend("", func() {
+		f.complete(ped.catchPanic("", PhaseEvent, "", func() error {
+			return dis.Trigger(newEvent())
+		}))
+	}); err != nil {
+		f.complete(err)
+	}
+	return f
+}
+
+// TriggerPluginsAsync is the async TriggerPlugins. The events to one plugin are delivered in order,
+// but plugins receives it concurrently.
+func (ped *PluginEventDispatcher) TriggerPluginsAsync(newEvent EventFactory, plugins ...*Plugin) *Future {
+	if len(plugins) == 0 {
+		plugins = ped.GetPlugins()
+	}
+	f := newFuture(len(plugins))
+	dis := ped.PluginDispatcher()
+	for _, p := range plugins {
+		p := p
+		if err := ped.asyncSend(p.UID(), func() {
+			f.complete(ped.catchPanic(p.UID(), PhaseEvent, "", func() error {
+				return dis.TriggerPlugins(newEvent(), p)
+			}))
+		}); err != nil {
+			f.complete(err)
+		}
+	}
+	return f
+}
+
+// CloseAsync stops the async queues workers and waits the delivery of queued events. After it, the
+// async triggers returns AsyncClosed. Do not call it from async handlers.
+func (ped *PluginEventDispatcher) CloseAsync() {
+	ped.asyncMu.Lock()
+	ped.asyncClosed = true
+	for _, q := range ped.asyncQueues {
+		close(q)
+	}
+	ped.asyncQueues = nil
+	ped.asyncMu.Unlock()
+	ped.asyncWorkers.Wait()
+}
@@ -0,0 +1,127 @@
+package pluggable
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type asyncPluginA struct{}
+type asyncPluginB struct{}
+
+type asyncTestEvent struct {
+	*PluginEvent
+	N int
+}
+
+func TestTriggerPluginsAsyncOrder(t *testing.T) {
+	pls := NewPlugins()
+	if err := pls.Add(&asyncPluginA{}, &asyncPluginB{}); err != nil {
+		t.Fatal(err)
+	}
+	var (
+		mu  sync.Mutex
+		got = map[string][]int{}
+	)
+	pls.OnPlugin("tick", func(e PluginEventInterface) {
+		te, ok := EventAs[*asyncTestEvent](e)
+		if !ok {
+			t.Errorf("unexpected event %T", e)
+			return
+		}
+		time.Sleep(time.Duration(te.N%3) * time.Millisecond)
+		mu.Lock()
+		got[e.Plugin().UID()] = append(got[e.Plugin().UID()], te.N)
+		mu.Unlock()
+	})
+
+	const n = 20
+	futures := make([]*Future, n)
+	for i := 0; i < n; i++ {
+		i := i
+		futures[i] = pls.TriggerPluginsAsync(func() EventInterface {
+			return &asyncTestEvent{NewPluginEvent("tick"), i}
+		})
+	}
+	for _, f := range futures {
+		if err := f.Wait(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, uid := range UIDs(&asyncPluginA{}, &asyncPluginB{}) {
+		if len(got[uid]) != n {
+			t.Fatalf("%s received %d events, want %d", uid, len(got[uid]), n)
+		}
+		for i, v := range got[uid] {
+			if v != i {
+				t.Fatalf("%s received events out of order: %v", uid, got[uid])
+			}
+		}
+	}
+}
+
+func TestCloseAsync(t *testing.T) {
+	pls := NewPlugins()
+	if err := pls.Add(&asyncPluginA{}); err != nil {
+		t.Fatal(err)
+	}
+	var (
+		mu    sync.Mutex
+		calls int
+	)
+	pls.OnPlugin("tick", func(e PluginEventInterface) {
+		time.Sleep(time.Millisecond)
+		mu.Lock()
+		calls++
+		mu.Unlock()
+	})
+	newEvent := func() EventInterface {
+		return NewPluginEvent("tick")
+	}
+	for i := 0; i < 5; i++ {
+		pls.TriggerPluginsAsync(newEvent)
+	}
+
+	// the queued events are delivered before CloseAsync returns
+	pls.CloseAsync()
+	if calls != 5 {
+		t.Errorf("calls = %d, want 5", calls)
+	}
+	if err := pls.TriggerPluginsAsync(newEvent).Wait(); !errors.Is(err, AsyncClosed) {
+		t.Errorf("Wait = %v, want AsyncClosed", err)
+	}
+}
+
+func TestAsyncQueueFull(t *testing.T) {
+	pls := NewPlugins()
+	if err := pls.Add(&asyncPluginA{}); err != nil {
+		t.Fatal(err)
+	}
+	pls.SetAsyncQueueSize(1)
+	started, release := make(chan struct{}, 3), make(chan struct{})
+	pls.OnPlugin("tick", func(e PluginEventInterface) {
+		started <- struct{}{}
+		<-release
+	})
+	newEvent := func() EventInterface {
+		return NewPluginEvent("tick")
+	}
+
+	// the first event is being delivered, the second is queued and the last does not fit
+	first := pls.TriggerPluginsAsync(newEvent)
+	<-started
+	queued := pls.TriggerPluginsAsync(newEvent)
+	err := pls.TriggerPluginsAsync(newEvent).Wait()
+	close(release)
+	if !errors.Is(err, AsyncFull) {
+		t.Errorf("Wait = %v, want AsyncFull", err)
+	}
+	for _, f := range []*Future{first, queued} {
+		if err = f.Wait(); err != nil {
+			t.Error(err)
+		}
+	}
+	pls.CloseAsync()
+}
@@ -0,0 +1,96 @@
+package pluggable
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// DependencyFailedError is the error of plugin skipped because a dependency failed.
+type DependencyFailedError struct {
+	UID        string
+	Dependency string
+}
+
+func (e DependencyFailedError) Error() string {
+	return fmt.Sprintf("Plugin %q skipped: dependency %q failed", e.UID, e.Dependency)
+}
+
+type PluginFailure struct {
+	Plugin *Plugin
+	Err    error
+}
+
+// BootReport is the report of plugins failed on Init in continue on error mode.
+type BootReport struct {
+	Failures []PluginFailure
+}
+
+func (r *BootReport) OK() bool {
+	return len(r.Failures) == 0
+}
+
+func (r *BootReport) String() string {
+	if r.OK() {
+		return "all plugins initialized"
+	}
+	lines := make([]string, len(r.Failures))
+	for i, f := range r.Failures {
+		lines[i] = fmt.Sprintf("  %s: %v", f.Plugin.UID(), f.Err)
+	}
+	return fmt.Sprintf("%d plugins failed:\n%s", len(r.Failures), strings.Join(lines, "\n"))
+}
+
+// SetContinueOnError enables the Init mode where a failing plugin, and their dependents, are marked
+// as failed and skipped while the rest initializes. See BootReport.
+func (pls *Plugins) SetContinueOnError(continueOnError bool) {
+	pls.continueOnError = continueOnError
+}
+
+// BootReport returns the report of failed plugins on Init.
+func (pls *Plugins) BootReport() *BootReport {
+	return &pls.bootReport
+}
+
+// Failed returns the Init error of plugin, if it failed.
+func (pls *Plugins) Failed(uid string) error {
+	return pls.failed[uid]
+}
+
+func (pls *Plugins) markFailed(p *Plugin, err error) {
+	if pls.failed == nil {
+		pls.failed = map[string]error{}
+	}
+	pls.failed[p.UID()] = err
+	pls.resetImplementing()
+	pls.removeServices(p)
+	pls.removeExtensions(p)
+	pls.bootReport.Failures = append(pls.bootReport.Failures, PluginFailure{p, err})
+	pls.log.Errorf("Plugin %q failed: %v", p.UID(), err)
+	var panicErr *PluginPanicError
+	if errors.As(err, &panicErr) && len(panicErr.Stack) > 0 {
+		pls.log.Errorf("Plugin %q panic stack:\n%s", p.UID(), panicErr.Stack)
+	}
+}
+
+// failedDependency returns the first failed dependency of plugin. Dependencies are initialized before,
+// so the transitive dependents are already marked as failed.
+func (pls *Plugins) failedDependency(p *Plugin) string {
+	for _, dep := range pls.deps[p.UID()] {
+		if _, ok := pls.failed[dep]; ok {
+			return dep
+		}
+	}
+	return ""
+}
+
+// activePlugins returns the plugins not failed.
+func (pls *Plugins) activePlugins() []*Plugin {
+	if len(pls.failed) == 0 {
+		return pls.plugins
+	}
+	return Filter(func(p *Plugin) bool {
+		_, failed := pls.failed[p.UID()]
+		return !failed
+	}, pls.plugins...)
+}
@@ -0,0 +1,90 @@
+package pluggable
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type bootService interface {
+	Name() string
+}
+
+type bootServiceImpl struct{}
+
+func (bootServiceImpl) Name() string {
+	return "service"
+}
+
+type bootProvider struct {
+	pls *Plugins
+}
+
+func (*bootProvider) ProvideServices() []reflect.Type {
+	return []reflect.Type{ServiceType[bootService]()}
+}
+
+func (b *bootProvider) Init() error {
+	if err := Provide[bootService](b.pls, bootServiceImpl{}); err != nil {
+		return err
+	}
+	return errors.New("fail")
+}
+
+type bootConsumer struct {
+	initialized bool
+}
+
+func (*bootConsumer) RequireServices() []reflect.Type {
+	return []reflect.Type{ServiceType[bootService]()}
+}
+
+func (b *bootConsumer) Init() {
+	b.initialized = true
+}
+
+type bootIndependent struct {
+	initialized bool
+}
+
+func (b *bootIndependent) Init() {
+	b.initialized = true
+}
+
+func TestContinueOnErrorFailedDependency(t *testing.T) {
+	pls := NewPlugins()
+	pls.SetContinueOnError(true)
+	provider, consumer, independent := &bootProvider{pls: pls}, &bootConsumer{}, &bootIndependent{}
+	if err := pls.Add(consumer, provider, independent); err != nil {
+		t.Fatal(err)
+	}
+	if err := pls.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := pls.Failed(UID(provider)); err == nil {
+		t.Error("provider is not failed")
+	}
+	var depErr DependencyFailedError
+	if !errors.As(pls.Failed(UID(consumer)), &depErr) || depErr.Dependency != UID(provider) {
+		t.Errorf("consumer error = %v, want DependencyFailedError", pls.Failed(UID(consumer)))
+	}
+	if consumer.initialized {
+		t.Error("consumer of failed provider is initialized")
+	}
+	if !independent.initialized {
+		t.Error("independent plugin is not initialized")
+	}
+	if n := len(pls.BootReport().Failures); n != 2 {
+		t.Errorf("boot report failures = %d, want 2", n)
+	}
+
+	// the services of failed plugins are dropped
+	var notFound ServiceNotFoundError
+	if _, err := Resolve[bootService](pls); !errors.As(err, &notFound) {
+		t.Errorf("Resolve = %v, want ServiceNotFoundError", err)
+	}
+	if active := pls.activePlugins(); len(active) != 1 || active[0].Value != independent {
+		t.Errorf("active plugins = %v, want only the independent", active)
+	}
+}
@@ -20,12 +20,21 @@ func (p PluginCallbackFunc) Call(pe PluginEventInterface) error {
 type PluginEventCallbackE func(e PluginEventInterface) error
 
 func (c PluginEventCallbackE) Call(e EventInterface) error {
-	return c(e.(PluginEventInterface))
+	return c(asPluginEvent(e))
 }
 
 type PluginEventCallback func(e PluginEventInterface)
 
 func (c PluginEventCallback) Call(e EventInterface) error {
-	c(e.(PluginEventInterface))
+	c(asPluginEvent(e))
 	return nil
 }
+
+// asPluginEvent returns e if it is a plugin event, otherwise wraps it, like the plain lifecycle events
+// received by pattern callbacks.
+func asPluginEvent(e EventInterface) PluginEventInterface {
+	if pe, ok := e.(PluginEventInterface); ok {
+		return pe
+	}
+	return &PluginEvent{EventInterface: e}
+}
@@ -0,0 +1,126 @@
+package pluggable
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+type DumpFormat string
+
+const (
+	DumpText DumpFormat = "text"
+	DumpJSON DumpFormat = "json"
+)
+
+type OptionReport struct {
+	Key        string   `json:"key"`
+	Value      string   `json:"value"`
+	Type       string   `json:"type"`
+	Secret     bool     `json:"secret,omitempty"`
+	Lazy       bool     `json:"lazy,omitempty"`
+	Source     string   `json:"source,omitempty"`
+	Provider   string   `json:"provider,omitempty"`
+	RequiredBy []string `json:"required_by,omitempty"`
+}
+
+// OptionsReport returns the report of all global options sorted by key, except the internal keys
+// (prefixed by PKG). Lazy options are not resolved. The file and env sources are reported only if
+// their loaders set the values by SetFrom (see OptionSourceFile).
+func (pls *Plugins) OptionsReport() (reports []OptionReport) {
+	var (
+		options    = pls.Options()
+		requiredBy = map[string][]string{}
+		keys       = options.Keys()
+	)
+
+	for _, p := range pls.plugins {
+		if requires, ok := p.Value.(PluginRequireOptions); ok {
+			for _, key := range requires.RequireOptions() {
+				requiredBy[key] = append(requiredBy[key], p.UID())
+			}
+		}
+	}
+
+	for key := range requiredBy {
+		if !options.Has(key) {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if strings.HasPrefix(key, PKG+".") {
+			continue
+		}
+		report := OptionReport{
+			Key:        key,
+			Source:     options.Source(key),
+			RequiredBy: requiredBy[key],
+		}
+		if p := pls.optionsProvider[key]; p != nil {
+			report.Provider = p.UID()
+		}
+		value, ok, lazy := options.peekLazy(key)
+		report.Lazy = lazy
+		switch {
+		case !ok:
+			report.Value = "<missing>"
+		case report.Lazy:
+			report.Value = "<lazy>"
+		default:
+			report.Type = fmt.Sprintf("%T", value)
+			_, report.Secret = value.(Secret)
+			report.Value = dumpValue(value)
+		}
+		reports = append(reports, report)
+	}
+	return
+}
+
+func dumpValue(value interface{}) string {
+	switch vt := value.(type) {
+	case nil:
+		return "<nil>"
+	case Secret:
+		return Redacted
+	case fmt.Stringer:
+		return vt.String()
+	case error:
+		return vt.Error()
+	}
+	switch reflect.TypeOf(value).Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return fmt.Sprint(value)
+	}
+	return fmt.Sprintf("<%T>", value)
+}
+
+// DumpOptions writes the global options report into w. Secret values are redacted.
+func (pls *Plugins) DumpOptions(w io.Writer, format DumpFormat) (err error) {
+	reports := pls.OptionsReport()
+	switch format {
+	case DumpJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(reports)
+	case DumpText, "":
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "KEY\tVALUE\tTYPE\tSOURCE\tPROVIDER\tREQUIRED BY")
+		for _, r := range reports {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", r.Key, r.Value, r.Type, r.Source, r.Provider,
+				strings.Join(r.RequiredBy, ", "))
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("Invalid dump format %q", format)
+	}
+}
@@ -1,8 +1,24 @@
 package pluggable
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
-	SortedError = errors.New("Sorted")
-	Initialized = errors.New("Initialized")
+	SortedError   = errors.New("Sorted")
+	Initialized   = errors.New("Initialized")
+	OptionsFrozen = errors.New("Options frozen")
+	AsyncClosed   = errors.New("Async queues closed")
+	AsyncFull     = errors.New("Async queue is full")
 )
+
+// PluginCancelledError is the error of plugin not added because the "register" event was cancelled.
+type PluginCancelledError struct {
+	UID    string
+	Reason string
+}
+
+func (e *PluginCancelledError) Error() string {
+	return fmt.Sprintf("Plugin %q registration cancelled: %s", e.UID, e.Reason)
+}
@@ -10,6 +10,11 @@ type PluginEventInterface interface {
 	Options() *Options
 	SetOptions(*Options)
 	WithPluginDispatcher(dis PluginEventDispatcherInterface) func()
+	StopPropagation()
+	PropagationStopped() bool
+	Cancel(reason string)
+	Cancelled() bool
+	CancelReason() string
 }
 
 type PluginEvent struct {
@@ -17,6 +22,10 @@ type PluginEvent struct {
 	plugin     *Plugin
 	options    *Options
 	dispatcher PluginEventDispatcherInterface
+	parent     EventInterface
+	stopped    bool
+	cancelled  bool
+	reason     string
 }
 
 type Parent struct {
@@ -36,6 +45,25 @@ func NewPluginEvent(e interface{}, data ...interface{}) (pe *PluginEvent) {
 	return
 }
 
+// Parent returns the event triggered by TriggerPlugins when this is the local "plugin:<NAME>" event.
+func (pe *PluginEvent) Parent() EventInterface {
+	return pe.parent
+}
+
+// EventAs returns e as T. If e is the local "plugin:<NAME>" event (see TriggerPlugins), their Parent
+// is used. Use it in OnPlugin handlers to access the triggered event, like the QueryEvent:
+//
+//	qe, ok := EventAs[*QueryEvent](e)
+func EventAs[T EventInterface](e EventInterface) (te T, ok bool) {
+	if te, ok = e.(T); ok {
+		return
+	}
+	if pe, isLocal := e.(*PluginEvent); isLocal && pe.Parent() != nil {
+		te, ok = pe.Parent().(T)
+	}
+	return
+}
+
 func (pe *PluginEvent) PluginDispatcher() PluginEventDispatcherInterface {
 	return pe.dispatcher
 }
@@ -49,7 +77,7 @@ func (pe *PluginEvent) SetPlugin(p *Plugin) {
 }
 
 func (pe *PluginEvent) Options() *Options {
-	if pe.options == nil {
+	if pe.options == nil && pe.dispatcher != nil {
 		return pe.dispatcher.Options()
 	}
 	return pe.options
@@ -66,3 +94,33 @@ func (pe *PluginEvent) WithPluginDispatcher(dis PluginEventDispatcherInterface)
 		pe.dispatcher = old
 	}
 }
+
+// StopPropagation stops the call of next handlers.
+func (pe *PluginEvent) StopPropagation() {
+	pe.stopped = true
+}
+
+// PropagationStopped returns if the propagation of this event or of their parent was stopped.
+func (pe *PluginEvent) PropagationStopped() bool {
+	if pe.stopped {
+		return true
+	}
+	if parent, ok := pe.parent.(PluginEventInterface); ok {
+		return parent.PropagationStopped()
+	}
+	return false
+}
+
+// Cancel vetoes the event action and stops the propagation. On "register" event, the plugin is not added.
+func (pe *PluginEvent) Cancel(reason string) {
+	pe.cancelled, pe.reason = true, reason
+	pe.StopPropagation()
+}
+
+func (pe *PluginEvent) Cancelled() bool {
+	return pe.cancelled
+}
+
+func (pe *PluginEvent) CancelReason() string {
+	return pe.reason
+}
@@ -3,6 +3,7 @@ package pluggable
 import (
 	"fmt"
 	"reflect"
+	"sync"
 
 	"github.com/moisespsena-go/edis"
 	errwrap "github.com/moisespsena-go/error-wrap"
@@ -11,14 +12,55 @@ import (
 
 type EventDispatcher struct {
 	edis.EventDispatcher
+	patternsMu sync.RWMutex
+	patterns   []patternCallback
+	sticky     map[string]EventInterface
+	recorder   *EventRecorder
 }
 
-func (ed *EventDispatcher) OnE(eventName string, callbacks ...interface{}) error {
-	return ed.EventDispatcher.OnE(eventName, prepareCallbacks(callbacks)...)
+// OnE registers the callbacks. The eventName accepts pattern (see IsEventPattern).
+// The sticky events (see TriggerSticky) are replayed to callbacks before the registration: if the
+// replay fails, the callbacks are not registered.
+func (ed *EventDispatcher) OnE(eventName string, callbacks ...interface{}) (err error) {
+	callbacks = prepareCallbacks(callbacks)
+	if err = ed.replaySticky(eventName, callbacks); err != nil {
+		return
+	}
+	return ed.on(eventName, callbacks)
 }
 
+// On is like OnE, but panics if callbacks are invalid. The sticky events replay errors are logged.
 func (ed *EventDispatcher) On(eventName string, callbacks ...interface{}) {
-	ed.EventDispatcher.On(eventName, prepareCallbacks(callbacks)...)
+	callbacks = prepareCallbacks(callbacks)
+	if err := ed.on(eventName, callbacks); err != nil {
+		panic(err)
+	}
+	if err := ed.replaySticky(eventName, callbacks); err != nil {
+		log.Errorf("On %q: %v", eventName, err)
+	}
+}
+
+func (ed *EventDispatcher) on(eventName string, callbacks []interface{}) error {
+	if IsEventPattern(eventName) {
+		return ed.onPattern(eventName, callbacks)
+	}
+	return ed.EventDispatcher.OnE(eventName, callbacks...)
+}
+
+// Trigger triggers the event to the callbacks registered by name and to callbacks registered by
+// pattern matching the event name.
+func (ed *EventDispatcher) Trigger(e EventInterface) (err error) {
+	end := ed.recorder.Begin(e.Name(), "", "")
+	defer func() {
+		end(err)
+	}()
+	if err = ed.EventDispatcher.Trigger(e); err != nil {
+		return
+	}
+	if pe, ok := e.(PluginEventInterface); ok && pe.PropagationStopped() {
+		return
+	}
+	return ed.triggerPatterns(e)
 }
 
 func prepareCallbacks(callbacks []interface{}) []interface{} {
@@ -35,8 +77,11 @@ func prepareCallbacks(callbacks []interface{}) []interface{} {
 
 type PluginEventDispatcherInterface interface {
 	EventDispatcherInterface
-	OnPluginE(eventName string, callbacks ...interface{}) error
-	OnPlugin(eventName string, callbacks ...interface{})
+	OnPluginE(eventName string, callbacks ...interface{}) (*Subscription, error)
+	OnPlugin(eventName string, callbacks ...interface{}) *Subscription
+	OnPluginWithOptionsE(eventName string, callback interface{}, opt ...HandlerOption) (*Subscription, error)
+	OnPluginWithOptions(eventName string, callback interface{}, opt ...HandlerOption) *Subscription
+	OncePlugin(eventName string, callback interface{}, opt ...HandlerOption) *Subscription
 	TriggerPlugins(e EventInterface, plugins ...*Plugin) (err error)
 	EachPlugins(items []*Plugin, cb func(plugin *Plugin) (err error)) (err error)
 	EachPluginsCallback(items []*Plugin, callbacks ...func(plugin *Plugin) error) (err error)
@@ -48,6 +93,20 @@ type PluginEventDispatcher struct {
 	EventDispatcher
 	options       *Options
 	PluginsGetter func() []*Plugin
+	noRecover     bool
+
+	asyncMu        sync.Mutex
+	asyncClosed    bool
+	asyncQueues    map[string]chan func()
+	asyncWorkers   sync.WaitGroup
+	asyncQueueSize int
+
+	handlersMu  sync.RWMutex
+	handlers    map[string][]*pluginHandler
+	handlersSeq int
+
+	stickyPlugins map[string]*stickyPluginsEvent
+	collectErrors bool
 }
 
 func (ped *PluginEventDispatcher) GetPlugins() []*Plugin {
@@ -56,10 +115,14 @@ func (ped *PluginEventDispatcher) GetPlugins() []*Plugin {
 
 func (ped *PluginEventDispatcher) SetOptions(options *Options) {
 	if ped.options != nil {
-		ped.options.Del(PKG + ".dispatcher")
+		if err := ped.options.Del(PKG + ".dispatcher"); err != nil {
+			log.Errorf("SetOptions: %v", err)
+		}
 	}
 
-	options.Set(PKG+".dispatcher", ped.PluginDispatcher())
+	if err := options.Set(PKG+".dispatcher", ped.PluginDispatcher()); err != nil {
+		log.Errorf("SetOptions: %v", err)
+	}
 	ped.options = options
 }
 
@@ -70,40 +133,39 @@ func (ped *PluginEventDispatcher) Options() *Options {
 func (ped *PluginEventDispatcher) SetDispatcher(dis EventDispatcherInterface) {
 	ped.EventDispatcher.SetDispatcher(dis)
 	if ped.options != nil {
-		ped.options.Set(PKG+".dispatcher", dis.(PluginEventDispatcherInterface))
+		if err := ped.options.Set(PKG+".dispatcher", dis.(PluginEventDispatcherInterface)); err != nil {
+			log.Errorf("SetDispatcher: %v", err)
+		}
 	}
 }
 
-func (ped *PluginEventDispatcher) OnPlugin(eventName string, callbacks ...interface{}) {
-	if err := ped.OnPluginE(eventName, callbacks...); err != nil {
-		panic(err)
+// OnPlugin is like OnPluginE, but panics if callbacks are invalid. The sticky events replay errors are
+// logged.
+func (ped *PluginEventDispatcher) OnPlugin(eventName string, callbacks ...interface{}) *Subscription {
+	for _, cb := range callbacks {
+		if _, err := pluginCallback(cb); err != nil {
+			panic(err)
+		}
 	}
+	sub := &Subscription{ped: ped, eventName: eventName}
+	for _, cb := range callbacks {
+		sub.handlers = append(sub.handlers, ped.OnPluginWithOptions(eventName, cb).handlers...)
+	}
+	return sub
 }
 
-func (ped *PluginEventDispatcher) OnPluginE(eventName string, callbacks ...interface{}) (err error) {
-	cbLocal := func(cbi PluginEventCallbackInterface) edis.CallbackFuncE {
-		return edis.CallbackFuncE(func(e EventInterface) error {
-			return cbi.Call(e.(PluginEventInterface))
-		})
-	}
-	var cbi PluginEventCallbackInterface
+// OnPluginE registers the plugin event callbacks. The returned subscription cancels all of them.
+func (ped *PluginEventDispatcher) OnPluginE(eventName string, callbacks ...interface{}) (sub *Subscription, err error) {
+	sub = &Subscription{ped: ped, eventName: eventName}
 	for _, cb := range callbacks {
-		switch t := cb.(type) {
-		case PluginEventCallbackInterface:
-			cbi = t
-		case func(e PluginEventInterface) error:
-			cbi = PluginCallbackFuncE(t)
-		case func(e PluginEventInterface):
-			cbi = PluginCallbackFunc(t)
-		default:
-			return fmt.Errorf("Invalid Callback type %s", t)
-		}
-		err = ped.OnE("plugin:"+eventName, cbLocal(cbi))
-		if err != nil {
-			return
+		var cbSub *Subscription
+		if cbSub, err = ped.OnPluginWithOptionsE(eventName, cb); err != nil {
+			sub.Cancel()
+			return nil, err
 		}
+		sub.handlers = append(sub.handlers, cbSub.handlers...)
 	}
-	return nil
+	return
 }
 
 func (ped *PluginEventDispatcher) PluginDispatcher() PluginEventDispatcherInterface {
@@ -125,7 +187,12 @@ func (ped *PluginEventDispatcher) TriggerPlugins(e EventInterface, plugins ...*P
 		defer pe.WithPluginDispatcher(dis)()
 	}
 
-	eLocal := &PluginEvent{&Event{PName: "plugin:" + e.Name()}, nil, dis.Options(), dis}
+	eLocal := &PluginEvent{
+		EventInterface: &Event{PName: "plugin:" + e.Name()},
+		options:        dis.Options(),
+		dispatcher:     dis,
+		parent:         pe,
+	}
 	err = ped.EachPluginsCallback(plugins, func(plugin *Plugin) (err error) {
 		log_ := ped.Logger()
 		if log_ == nil {
@@ -134,11 +201,26 @@ func (ped *PluginEventDispatcher) TriggerPlugins(e EventInterface, plugins ...*P
 		log_ = logging.WithPrefix(log_, "trigger -> " + e.Name())
 		log_.Debug("start")
 		defer log_.Debug("done")
+		end := ped.recorder.Begin(e.Name(), plugin.UID(), "")
+		defer func() {
+			end(err)
+		}()
 		eLocal.plugin = plugin
+		eLocal.stopped, eLocal.cancelled, eLocal.reason = false, false, ""
+		eLocal.SetError(nil)
+		if ped.collectErrors {
+			// the error of previous plugin was collected
+			pe.SetError(nil)
+		}
 		if err = func()(err error) {
 			log_.Debug("local -> start")
 			defer log_.Debug("local -> done")
-			if err = ped.Trigger(eLocal); err == nil {
+			if err = ped.catchPanic(plugin.UID(), PhaseEvent, eLocal.Name(), func() error {
+				if err := ped.Trigger(eLocal); err != nil || eLocal.PropagationStopped() {
+					return err
+				}
+				return ped.callPatternHandlers(e.Name(), eLocal)
+			}); err == nil {
 				if eLocal.Error() != nil {
 					return eLocal.Error()
 				}
@@ -147,6 +229,12 @@ func (ped *PluginEventDispatcher) TriggerPlugins(e EventInterface, plugins ...*P
 		}(); err != nil {
 			return
 		}
+		if eLocal.Cancelled() {
+			pe.Cancel(eLocal.CancelReason())
+		}
+		if eLocal.PropagationStopped() {
+			return nil
+		}
 		if ed, ok := plugin.Value.(EventDispatcherInterface); ok {
 			pe.SetPlugin(plugin)
 			if err != nil {
@@ -155,11 +243,13 @@ func (ped *PluginEventDispatcher) TriggerPlugins(e EventInterface, plugins ...*P
 			msg := "value -> "+fmt.Sprintf("%T", plugin.Value) + " -> "
 			log_.Debug(msg+"start")
 			defer log_.Debug(msg+"done")
-			if err = ed.Trigger(pe); err == nil {
-				if pe.Error() != nil {
-					return pe.Error()
-				}
+			endValue := ped.recorder.Begin(e.Name(), plugin.UID(), fmt.Sprintf("%T", plugin.Value))
+			if err = ped.catchPanic(plugin.UID(), PhaseEvent, e.Name(), func() error {
+				return ed.Trigger(pe)
+			}); err == nil {
+				err = pe.Error()
 			}
+			endValue(err)
 			if err != nil {
 				return
 			}
@@ -167,13 +257,27 @@ func (ped *PluginEventDispatcher) TriggerPlugins(e EventInterface, plugins ...*P
 		return nil
 	})
 	if err != nil && e.Error() == nil {
-		err = errwrap.Wrap(err, "Trigger %v", e.Name())
+		// collected errors are returned as is, so callers can use errors.As(err, **MultiError)
+		if _, collected := err.(*MultiError); !collected {
+			err = errwrap.Wrap(err, "Trigger %v", e.Name())
+		}
 		e.SetError(err)
 	}
 	return
 }
 
 func (ped *PluginEventDispatcher) EachPluginsCallback(items []*Plugin, callbacks ...func(plugin *Plugin) error) (err error) {
+	if ped.collectErrors {
+		var errs MultiError
+		for _, plugin := range items {
+			for _, cb := range callbacks {
+				if err = cb(plugin); err != nil {
+					errs.add(&PluginError{plugin.UID(), callbackName(cb), err})
+				}
+			}
+		}
+		return errs.err()
+	}
 	err = ped.EachPlugins(items, func(plugin *Plugin) (err error) {
 		for _, cb := range callbacks {
 			err = cb(plugin)
@@ -191,6 +295,15 @@ func (ped *PluginEventDispatcher) EachPluginsCallback(items []*Plugin, callbacks
 }
 
 func (ped *PluginEventDispatcher) EachPlugins(items []*Plugin, cb func(plugin *Plugin) (err error)) (err error) {
+	if ped.collectErrors {
+		var errs MultiError
+		for _, plugin := range items {
+			if err = cb(plugin); err != nil {
+				errs.add(&PluginError{UID: plugin.UID(), Err: err})
+			}
+		}
+		return errs.err()
+	}
 	for _, plugin := range items {
 		err = cb(plugin)
 		if err != nil {
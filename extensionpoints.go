@@ -0,0 +1,159 @@
+package pluggable
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+type extensionPoint struct {
+	name  string
+	typ   reflect.Type
+	owner *Plugin
+}
+
+type contribution struct {
+	point  string
+	plugin *Plugin
+	value  interface{}
+}
+
+// Contribution is an item contributed to extension point by plugin.
+type Contribution[T any] struct {
+	Plugin *Plugin
+	Value  T
+}
+
+// ExtensionPointOf is the typed extension point named Name.
+type ExtensionPointOf[T any] struct {
+	pls  *Plugins
+	Name string
+}
+
+// ExtensionPoint declares the extension point name, owned by plugin in registration or initialization.
+// The contributions must be assignable to T.
+func ExtensionPoint[T any](pls *Plugins, name string) (ep *ExtensionPointOf[T], err error) {
+	typ := ServiceType[T]()
+	pls.extensionsMu.Lock()
+	defer pls.extensionsMu.Unlock()
+	if old, ok := pls.extensionPoints[name]; ok {
+		return nil, fmt.Errorf("Extension point %q already declared by %v", name, old.owner)
+	}
+	if pls.extensionPoints == nil {
+		pls.extensionPoints = map[string]*extensionPoint{}
+	}
+	pls.extensionPoints[name] = &extensionPoint{name, typ, pls.current.Load()}
+	return &ExtensionPointOf[T]{pls, name}, nil
+}
+
+// Contributions returns the contributions in plugins dependency order. Read it after initDone.
+func (ep *ExtensionPointOf[T]) Contributions() (items []Contribution[T]) {
+	for _, c := range ep.pls.contributionsOf(ep.Name) {
+		if value, ok := c.value.(T); ok {
+			items = append(items, Contribution[T]{c.plugin, value})
+		}
+	}
+	return
+}
+
+// Values returns the contributed values in plugins dependency order. Read it after initDone.
+func (ep *ExtensionPointOf[T]) Values() (values []T) {
+	for _, c := range ep.Contributions() {
+		values = append(values, c.Value)
+	}
+	return
+}
+
+// Contribute adds the item into extension point name by plugin in registration or initialization.
+// The contributions are validated on Init, before initDone, or immediately after it.
+func (pls *Plugins) Contribute(name string, item interface{}) (err error) {
+	return pls.ContributeBy(pls.current.Load(), name, item)
+}
+
+func (pls *Plugins) ContributeBy(plugin *Plugin, name string, item interface{}) (err error) {
+	c := &contribution{name, plugin, item}
+	pls.extensionsMu.Lock()
+	defer pls.extensionsMu.Unlock()
+	if pls.contributionsValidated {
+		if err = pls.validateContribution(c); err != nil {
+			return
+		}
+	}
+	pls.contributions = append(pls.contributions, c)
+	return
+}
+
+func (pls *Plugins) validateContribution(c *contribution) error {
+	point, ok := pls.extensionPoints[c.point]
+	if !ok {
+		return fmt.Errorf("Plugin %v contributes to unknown extension point %q", c.plugin, c.point)
+	}
+	if c.value == nil || !reflect.TypeOf(c.value).AssignableTo(point.typ) {
+		return fmt.Errorf("Plugin %v contributes %T to extension point %q, expected %s", c.plugin, c.value,
+			c.point, point.typ)
+	}
+	return nil
+}
+
+// validateContributions validates the contributions added before Init done. In continue on error mode,
+// the plugins of invalid contributions are marked as failed and their contributions are removed.
+func (pls *Plugins) validateContributions() (err error) {
+	var invalid []*PluginError
+	pls.extensionsMu.Lock()
+	pls.contributionsValidated = true
+	for _, c := range pls.contributions {
+		if err = pls.validateContribution(c); err != nil {
+			if !pls.continueOnError || c.plugin == nil {
+				pls.extensionsMu.Unlock()
+				return
+			}
+			invalid = append(invalid, &PluginError{UID: c.plugin.UID(), Err: err})
+			err = nil
+		}
+	}
+	pls.extensionsMu.Unlock()
+
+	for _, e := range invalid {
+		if p := pls.ByUID.Get(e.UID); p != nil && pls.Failed(e.UID) == nil {
+			pls.markFailed(p, e.Err)
+		}
+	}
+	return
+}
+
+func (pls *Plugins) contributionsOf(name string) (result []*contribution) {
+	pls.extensionsMu.RLock()
+	for _, c := range pls.contributions {
+		if c.point == name {
+			result = append(result, c)
+		}
+	}
+	pls.extensionsMu.RUnlock()
+
+	order := make(map[*Plugin]int, len(pls.plugins))
+	for i, p := range pls.plugins {
+		order[p] = i
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return order[result[i].plugin] < order[result[j].plugin]
+	})
+	return
+}
+
+// removeExtensions removes the extension points declared and the contributions added by plugin.
+func (pls *Plugins) removeExtensions(plugin *Plugin) {
+	pls.extensionsMu.Lock()
+	defer pls.extensionsMu.Unlock()
+	for name, point := range pls.extensionPoints {
+		if point.owner == plugin {
+			delete(pls.extensionPoints, name)
+		}
+	}
+	var keep []*contribution
+	for _, c := range pls.contributions {
+		if c.plugin != plugin {
+			keep = append(keep, c)
+		}
+	}
+	pls.contributions = keep
+}
@@ -1,5 +1,149 @@
 package pluggable
 
+import (
+	"sort"
+
+	errwrap "github.com/moisespsena-go/error-wrap"
+)
+
 type Extension interface {
 	Init(plugins *Plugins) error
 }
+
+// ExtensionOrder defines the extension call order. Lower values are called first. The default order is 0.
+type ExtensionOrder interface {
+	Order() int
+}
+
+// ExtensionPluginAdded is called after plugin registration. Late extensions receives all registered plugins.
+type ExtensionPluginAdded interface {
+	OnPluginAdded(p *Plugin) error
+}
+
+type ExtensionBeforePluginInit interface {
+	BeforePluginInit(p *Plugin) error
+}
+
+type ExtensionAfterPluginInit interface {
+	AfterPluginInit(p *Plugin) error
+}
+
+// ExtensionInitDone is called after initDone event. Late extensions receives it on add.
+type ExtensionInitDone interface {
+	OnInitDone(plugins *Plugins) error
+}
+
+// ExtensionStop is called by Plugins.Stop, in reverse order.
+type ExtensionStop interface {
+	OnStop(plugins *Plugins) error
+}
+
+func extensionOrder(extension Extension) int {
+	if o, ok := extension.(ExtensionOrder); ok {
+		return o.Order()
+	}
+	return 0
+}
+
+func (pls *Plugins) sortExtensions() {
+	sort.SliceStable(pls.Extensions, func(i, j int) bool {
+		return extensionOrder(pls.Extensions[i]) < extensionOrder(pls.Extensions[j])
+	})
+}
+
+func (pls *Plugins) eachExtension(f func(extension Extension) error) (err error) {
+	for _, extension := range pls.Extensions {
+		if err = f(extension); err != nil {
+			return errwrap.Wrap(err, "Extension %T", extension)
+		}
+	}
+	return
+}
+
+func (pls *Plugins) extensionsPluginAdded(p *Plugin) error {
+	return pls.eachExtension(func(extension Extension) error {
+		if e, ok := extension.(ExtensionPluginAdded); ok {
+			return e.OnPluginAdded(p)
+		}
+		return nil
+	})
+}
+
+func (pls *Plugins) extensionsBeforePluginInit(p *Plugin) error {
+	return pls.eachExtension(func(extension Extension) error {
+		if e, ok := extension.(ExtensionBeforePluginInit); ok {
+			return e.BeforePluginInit(p)
+		}
+		return nil
+	})
+}
+
+func (pls *Plugins) extensionsAfterPluginInit(p *Plugin) error {
+	return pls.eachExtension(func(extension Extension) error {
+		if e, ok := extension.(ExtensionAfterPluginInit); ok {
+			return e.AfterPluginInit(p)
+		}
+		return nil
+	})
+}
+
+func (pls *Plugins) extensionsInitDone() error {
+	return pls.eachExtension(func(extension Extension) error {
+		if e, ok := extension.(ExtensionInitDone); ok {
+			return e.OnInitDone(pls)
+		}
+		return nil
+	})
+}
+
+// lateExtension initializes the extension added after Init and replays the already registered plugins.
+func (pls *Plugins) lateExtension(extension Extension) (err error) {
+	if err = extension.Init(pls); err != nil {
+		return
+	}
+	if ed, ok := extension.(EventDispatcherInterface); ok {
+		if err = pls.EachPlugins(pls.activePlugins(), func(plugin *Plugin) (err error) {
+			return ed.Trigger(NewEvent("pluginRegister", plugin))
+		}); err != nil {
+			return
+		}
+	}
+	if e, ok := extension.(ExtensionPluginAdded); ok {
+		if err = pls.EachPlugins(pls.activePlugins(), e.OnPluginAdded); err != nil {
+			return
+		}
+	}
+	if e, ok := extension.(ExtensionInitDone); ok && pls.initDone.Load() {
+		return e.OnInitDone(pls)
+	}
+	return
+}
+
+// Stop stops the plugins implementing PluginStop, the async queues and calls the extensions OnStop.
+// Plugins and extensions are called in reverse order. All of them are called, the first error is returned.
+// The failed plugins are not stopped.
+func (pls *Plugins) Stop() (err error) {
+	setErr := func(e error) {
+		if err == nil {
+			err = e
+		}
+	}
+	plugins := pls.activePlugins()
+	for i := len(plugins) - 1; i >= 0; i-- {
+		p := plugins[i]
+		if s, ok := p.Value.(PluginStop); ok {
+			if e := s.Stop(); e != nil {
+				setErr(errwrap.Wrap(e, "Plugin %q Stop", p.UID()))
+			}
+		}
+	}
+	pls.CloseAsync()
+	for i := len(pls.Extensions) - 1; i >= 0; i-- {
+		if s, ok := pls.Extensions[i].(ExtensionStop); ok {
+			if e := s.OnStop(pls); e != nil {
+				setErr(errwrap.Wrap(e, "Extension %T Stop", s))
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,286 @@
+package pluggable
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/moisespsena-go/edis"
+)
+
+type pluginHandler struct {
+	id            string
+	name          string
+	priority      int
+	seq           int
+	before, after []string
+	cb            PluginEventCallbackInterface
+	once          bool
+	// done is 1 if handler was cancelled or the once handler was fired
+	done int32
+}
+
+// Subscription is the handle of registered plugin event handlers.
+type Subscription struct {
+	ped       *PluginEventDispatcher
+	eventName string
+	handlers  []*pluginHandler
+}
+
+// Cancel removes the handlers. It is safe to call while the event is being dispatched: the cancelled
+// handlers are not called anymore.
+func (s *Subscription) Cancel() {
+	if s == nil {
+		return
+	}
+	for _, h := range s.handlers {
+		atomic.StoreInt32(&h.done, 1)
+	}
+	s.ped.removeHandlers(s.eventName, s.handlers...)
+}
+
+type HandlerOption func(h *pluginHandler)
+
+// HandlerID sets the handler identifier, referenced by Before and After of other handlers.
+func HandlerID(id string) HandlerOption {
+	return func(h *pluginHandler) {
+		h.id = id
+	}
+}
+
+// Priority sets the handler priority. Higher priorities runs first. The default is 0.
+func Priority(priority int) HandlerOption {
+	return func(h *pluginHandler) {
+		h.priority = priority
+	}
+}
+
+// Before makes the handler run before the handlers identified by ids.
+func Before(id ...string) HandlerOption {
+	return func(h *pluginHandler) {
+		h.before = append(h.before, id...)
+	}
+}
+
+// After makes the handler run after the handlers identified by ids.
+func After(id ...string) HandlerOption {
+	return func(h *pluginHandler) {
+		h.after = append(h.after, id...)
+	}
+}
+
+func pluginCallback(cb interface{}) (cbi PluginEventCallbackInterface, err error) {
+	switch t := cb.(type) {
+	case PluginEventCallbackInterface:
+		cbi = t
+	case func(e PluginEventInterface) error:
+		cbi = PluginCallbackFuncE(t)
+	case func(e PluginEventInterface):
+		cbi = PluginCallbackFunc(t)
+	default:
+		err = fmt.Errorf("Invalid Callback type %T", t)
+	}
+	return
+}
+
+// sortHandlers orders handlers by priority and registration, respecting Before and After constraints.
+func sortHandlers(handlers []*pluginHandler) (result []*pluginHandler, err error) {
+	base := append([]*pluginHandler{}, handlers...)
+	sort.SliceStable(base, func(i, j int) bool {
+		if base[i].priority != base[j].priority {
+			return base[i].priority > base[j].priority
+		}
+		return base[i].seq < base[j].seq
+	})
+
+	var (
+		byID     = map[string][]*pluginHandler{}
+		next     = map[*pluginHandler][]*pluginHandler{}
+		indegree = map[*pluginHandler]int{}
+	)
+	for _, h := range base {
+		if h.id != "" {
+			byID[h.id] = append(byID[h.id], h)
+		}
+	}
+	edge := func(from, to *pluginHandler) {
+		next[from] = append(next[from], to)
+		indegree[to]++
+	}
+	for _, h := range base {
+		for _, id := range h.before {
+			for _, other := range byID[id] {
+				edge(h, other)
+			}
+		}
+		for _, id := range h.after {
+			for _, other := range byID[id] {
+				edge(other, h)
+			}
+		}
+	}
+
+	for len(base) > 0 {
+		i := 0
+		for i < len(base) && indegree[base[i]] > 0 {
+			i++
+		}
+		if i == len(base) {
+			ids := make([]string, len(base))
+			for i, h := range base {
+				ids[i] = h.id
+			}
+			return nil, fmt.Errorf("Handlers order cycle: %s", strings.Join(ids, ", "))
+		}
+		h := base[i]
+		base = append(base[:i], base[i+1:]...)
+		result = append(result, h)
+		for _, other := range next[h] {
+			indegree[other]--
+		}
+	}
+	return
+}
+
+// OnPluginWithOptions is like OnPluginWithOptionsE, but panics if callback is invalid. The sticky events
+// replay errors are logged.
+func (ped *PluginEventDispatcher) OnPluginWithOptions(eventName string, callback interface{}, opt ...HandlerOption) *Subscription {
+	sub, replayErr, err := ped.onPlugin(eventName, callback, opt...)
+	if err != nil {
+		panic(err)
+	}
+	if replayErr != nil {
+		log.Errorf("OnPlugin %q: %v", eventName, replayErr)
+	}
+	return sub
+}
+
+// OncePlugin registers the plugin event callback removed after the first call.
+func (ped *PluginEventDispatcher) OncePlugin(eventName string, callback interface{}, opt ...HandlerOption) *Subscription {
+	return ped.OnPluginWithOptions(eventName, callback, append(opt, func(h *pluginHandler) {
+		h.once = true
+	})...)
+}
+
+// OnPluginWithOptionsE registers the plugin event callback with handler options (see Priority, Before,
+// After and HandlerID). The eventName accepts pattern (see IsEventPattern): the handler receives the
+// local event named "plugin:<CONCRETE NAME>". If the sticky events replay fails, the handler is removed.
+func (ped *PluginEventDispatcher) OnPluginWithOptionsE(eventName string, callback interface{}, opt ...HandlerOption) (sub *Subscription, err error) {
+	var replayErr error
+	if sub, replayErr, err = ped.onPlugin(eventName, callback, opt...); err != nil {
+		return nil, err
+	}
+	if replayErr != nil {
+		sub.Cancel()
+		return nil, replayErr
+	}
+	return
+}
+
+// onPlugin registers the handler and replays the sticky events to it.
+func (ped *PluginEventDispatcher) onPlugin(eventName string, callback interface{}, opt ...HandlerOption) (sub *Subscription, replayErr, err error) {
+	h := &pluginHandler{}
+	if h.cb, err = pluginCallback(callback); err != nil {
+		return
+	}
+	for _, o := range opt {
+		o(h)
+	}
+	if h.name = h.id; h.name == "" {
+		h.name = callbackName(callback)
+	}
+	sub = &Subscription{ped, eventName, []*pluginHandler{h}}
+
+	var registered bool
+	if registered, err = ped.addHandler(eventName, h); err != nil {
+		return nil, nil, err
+	}
+	if !registered && !IsEventPattern(eventName) {
+		if err = ped.OnE("plugin:"+eventName, edis.CallbackFuncE(func(e EventInterface) error {
+			return ped.callHandlers(eventName, asPluginEvent(e))
+		})); err != nil {
+			sub.Cancel()
+			ped.handlersMu.Lock()
+			if len(ped.handlers[eventName]) == 0 {
+				// the edis callback is not registered
+				delete(ped.handlers, eventName)
+			}
+			ped.handlersMu.Unlock()
+			return nil, nil, err
+		}
+	}
+	replayErr = ped.replaySticky(eventName, h)
+	return
+}
+
+// addHandler adds the handler and returns if eventName already has handlers.
+func (ped *PluginEventDispatcher) addHandler(eventName string, h *pluginHandler) (registered bool, err error) {
+	ped.handlersMu.Lock()
+	defer ped.handlersMu.Unlock()
+	ped.handlersSeq++
+	h.seq = ped.handlersSeq
+
+	if ped.handlers == nil {
+		ped.handlers = map[string][]*pluginHandler{}
+	}
+	var handlers []*pluginHandler
+	handlers, registered = ped.handlers[eventName]
+	if handlers, err = sortHandlers(append(handlers, h)); err != nil {
+		return
+	}
+	ped.handlers[eventName] = handlers
+	return
+}
+
+func (ped *PluginEventDispatcher) removeHandlers(eventName string, remove ...*pluginHandler) {
+	ped.handlersMu.Lock()
+	defer ped.handlersMu.Unlock()
+	if _, ok := ped.handlers[eventName]; !ok {
+		return
+	}
+	var handlers []*pluginHandler
+main:
+	for _, h := range ped.handlers[eventName] {
+		for _, r := range remove {
+			if h == r {
+				continue main
+			}
+		}
+		handlers = append(handlers, h)
+	}
+	// keeps the key: the edis callback is registered
+	ped.handlers[eventName] = handlers
+}
+
+func (ped *PluginEventDispatcher) callHandlers(eventName string, e PluginEventInterface) (err error) {
+	ped.handlersMu.RLock()
+	handlers := ped.handlers[eventName]
+	ped.handlersMu.RUnlock()
+	for _, h := range handlers {
+		if err = ped.callHandler(eventName, h, e); err != nil || e.PropagationStopped() {
+			return
+		}
+	}
+	return
+}
+
+func (ped *PluginEventDispatcher) callHandler(eventName string, h *pluginHandler, e PluginEventInterface) (err error) {
+	if h.once {
+		if !atomic.CompareAndSwapInt32(&h.done, 0, 1) {
+			return nil
+		}
+		ped.removeHandlers(eventName, h)
+	} else if atomic.LoadInt32(&h.done) == 1 {
+		return nil
+	}
+	var uid string
+	if p := e.Plugin(); p != nil {
+		uid = p.UID()
+	}
+	end := ped.recorder.Begin(e.Name(), uid, h.name)
+	defer func() {
+		end(err)
+	}()
+	return h.cb.Call(e)
+}
@@ -0,0 +1,85 @@
+package pluggable
+
+import (
+	"strings"
+	"testing"
+)
+
+func handlerIDs(handlers []*pluginHandler) string {
+	ids := make([]string, len(handlers))
+	for i, h := range handlers {
+		ids[i] = h.id
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestSortHandlers(t *testing.T) {
+	tests := []struct {
+		name     string
+		handlers []*pluginHandler
+		want     string
+	}{
+		{"registration order", []*pluginHandler{
+			{id: "a", seq: 1},
+			{id: "b", seq: 2},
+			{id: "c", seq: 3},
+		}, "a,b,c"},
+		{"priority", []*pluginHandler{
+			{id: "a", seq: 1},
+			{id: "b", seq: 2, priority: 10},
+			{id: "c", seq: 3, priority: -1},
+		}, "b,a,c"},
+		{"before", []*pluginHandler{
+			{id: "a", seq: 1},
+			{id: "b", seq: 2, before: []string{"a"}},
+		}, "b,a"},
+		{"after overrides priority", []*pluginHandler{
+			{id: "a", seq: 1},
+			{id: "b", seq: 2, priority: 10, after: []string{"a"}},
+		}, "a,b"},
+		{"unknown id is ignored", []*pluginHandler{
+			{id: "a", seq: 1, after: []string{"x"}},
+			{id: "b", seq: 2},
+		}, "a,b"},
+		{"chain", []*pluginHandler{
+			{id: "c", seq: 1, after: []string{"b"}},
+			{id: "b", seq: 2, after: []string{"a"}},
+			{id: "a", seq: 3},
+		}, "a,b,c"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := sortHandlers(tt.handlers)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := handlerIDs(result); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSortHandlersDoesNotChangeInput(t *testing.T) {
+	handlers := []*pluginHandler{{id: "a", seq: 1}, {id: "b", seq: 2, priority: 1}}
+	if _, err := sortHandlers(handlers); err != nil {
+		t.Fatal(err)
+	}
+	if got := handlerIDs(handlers); got != "a,b" {
+		t.Errorf("input changed: %s", got)
+	}
+}
+
+func TestSortHandlersCycle(t *testing.T) {
+	_, err := sortHandlers([]*pluginHandler{
+		{id: "a", seq: 1, after: []string{"b"}},
+		{id: "b", seq: 2, after: []string{"a"}},
+		{id: "c", seq: 3},
+	})
+	if err == nil {
+		t.Fatal("expected cycle error")
+	}
+	if msg := err.Error(); !strings.HasSuffix(msg, ": a, b") {
+		t.Errorf("unexpected error: %s", msg)
+	}
+}
@@ -0,0 +1,155 @@
+package pluggable
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type HealthState string
+
+const (
+	HealthUp       HealthState = "up"
+	HealthDegraded HealthState = "degraded"
+	HealthDown     HealthState = "down"
+)
+
+// DefaultHealthTimeout is the per-check timeout used if plugin does not implements HealthTimeout.
+var DefaultHealthTimeout = 5 * time.Second
+
+type HealthStatus struct {
+	State   HealthState `json:"state"`
+	Message string      `json:"message,omitempty"`
+	// Cause is the UID of unhealthy dependency.
+	Cause string `json:"cause,omitempty"`
+}
+
+type HealthChecker interface {
+	Health(ctx context.Context) HealthStatus
+}
+
+type HealthTimeout interface {
+	HealthTimeout() time.Duration
+}
+
+type HealthReport struct {
+	State   HealthState             `json:"state"`
+	Plugins map[string]HealthStatus `json:"plugins,omitempty"`
+}
+
+func healthWorst(a, b HealthState) HealthState {
+	if a == HealthDown || b == HealthDown {
+		return HealthDown
+	}
+	if a == HealthDegraded || b == HealthDegraded {
+		return HealthDegraded
+	}
+	return HealthUp
+}
+
+func checkHealth(ctx context.Context, p *Plugin, checker HealthChecker) (status HealthStatus) {
+	timeout := DefaultHealthTimeout
+	if t, ok := p.Value.(HealthTimeout); ok {
+		timeout = t.HealthTimeout()
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	result := make(chan HealthStatus, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				result <- HealthStatus{State: HealthDown, Message: (&PluginPanicError{UID: p.UID(), Phase: "health", Value: r}).Error()}
+			}
+		}()
+		result <- checker.Health(ctx)
+	}()
+
+	select {
+	case status = <-result:
+	case <-ctx.Done():
+		status = HealthStatus{State: HealthDown, Message: "health check: " + ctx.Err().Error()}
+	}
+	if status.State == "" {
+		status.State = HealthUp
+	}
+	return
+}
+
+// Health runs the plugins health checks concurrently and aggregates them by plugin UID. Plugins with
+// unhealthy dependencies are marked as degraded. Plugins failed on Init are degraded.
+func (pls *Plugins) Health(ctx context.Context) (report HealthReport) {
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	report = HealthReport{State: HealthUp, Plugins: map[string]HealthStatus{}}
+
+	for _, p := range pls.plugins {
+		if err := pls.Failed(p.UID()); err != nil {
+			// the boot continued without it (see SetContinueOnError)
+			report.Plugins[p.UID()] = HealthStatus{State: HealthDegraded, Message: err.Error()}
+			continue
+		}
+		if checker, ok := p.Value.(HealthChecker); ok {
+			wg.Add(1)
+			go func(p *Plugin, checker HealthChecker) {
+				defer wg.Done()
+				status := checkHealth(ctx, p, checker)
+				mu.Lock()
+				report.Plugins[p.UID()] = status
+				mu.Unlock()
+			}(p, checker)
+		}
+	}
+	wg.Wait()
+
+	// plugins are sorted, so dependencies status are resolved before dependents
+	for _, p := range pls.plugins {
+		uid := p.UID()
+		status, checked := report.Plugins[uid]
+		if !checked || status.State == HealthUp {
+			for _, dep := range pls.deps[uid] {
+				if depStatus, ok := report.Plugins[dep]; ok && depStatus.State != HealthUp {
+					status = HealthStatus{State: HealthDegraded, Message: status.Message, Cause: dep}
+					report.Plugins[uid] = status
+					break
+				}
+			}
+		}
+		if status.State != "" {
+			report.State = healthWorst(report.State, status.State)
+		}
+	}
+	return
+}
+
+func writeHealth(w http.ResponseWriter, report HealthReport) {
+	w.Header().Set("Content-Type", "application/json")
+	if report.State == HealthDown {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(report)
+}
+
+// LivenessHandler responds 200 while the process is running, even during Init. It does not run health
+// checks.
+func (pls *Plugins) LivenessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeHealth(w, HealthReport{State: HealthUp})
+	})
+}
+
+// ReadinessHandler runs the health checks and responds the report. The status is 503 before Init done
+// or if any plugin is down.
+func (pls *Plugins) ReadinessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := HealthReport{State: HealthDown}
+		if pls.initDone.Load() {
+			report = pls.Health(r.Context())
+		}
+		writeHealth(w, report)
+	})
+}
@@ -0,0 +1,43 @@
+package pluggable
+
+import "reflect"
+
+// Implementing returns the values of plugins implementing T, in plugins order (sorted after Init).
+func Implementing[T any](pls *Plugins) (values []T) {
+	for _, p := range pls.PluginsImplementing(ServiceType[T]()) {
+		values = append(values, p.Value.(T))
+	}
+	return
+}
+
+// PluginsImplementing returns the plugins whose value implements the interface typ (or is assignable
+// to typ), in plugins order (sorted after Init), excluding the failed plugins. The result is cached
+// until plugins changes.
+func (pls *Plugins) PluginsImplementing(typ reflect.Type) (plugins []*Plugin) {
+	pls.implementingMu.RLock()
+	plugins, ok := pls.implementing[typ]
+	pls.implementingMu.RUnlock()
+	if ok {
+		return
+	}
+
+	for _, p := range pls.activePlugins() {
+		if p.Value != nil && reflect.TypeOf(p.Value).AssignableTo(typ) {
+			plugins = append(plugins, p)
+		}
+	}
+
+	pls.implementingMu.Lock()
+	defer pls.implementingMu.Unlock()
+	if pls.implementing == nil {
+		pls.implementing = map[reflect.Type][]*Plugin{}
+	}
+	pls.implementing[typ] = plugins
+	return
+}
+
+func (pls *Plugins) resetImplementing() {
+	pls.implementingMu.Lock()
+	defer pls.implementingMu.Unlock()
+	pls.implementing = nil
+}
@@ -0,0 +1,192 @@
+package pluggable
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/moisespsena-go/logging"
+)
+
+// InjectTag is the struct tag name of injected fields. Valid values are:
+//
+//	`pluggable:"option=KEY"`  sets the value of global option KEY
+//	`pluggable:"service"`     sets the service resolved by field type. For slice fields, sets all services
+//	`pluggable:"plugin=UID"`  sets the plugin value (or *Plugin, if field type is it)
+//	`pluggable:"logger"`      sets the plugin logger
+const InjectTag = "pluggable"
+
+const (
+	injectOption  = "option"
+	injectService = "service"
+	injectPlugin  = "plugin"
+	injectLogger  = "logger"
+)
+
+var (
+	pluginType     = reflect.TypeOf((*Plugin)(nil))
+	loggerType     = reflect.TypeOf((*logging.Logger)(nil)).Elem()
+	injectFieldsOf sync.Map
+)
+
+type injectField struct {
+	name  string
+	index int
+	typ   reflect.Type
+	kind  string
+	arg   string
+}
+
+// serviceType returns the service type of field and if all services of it are required.
+func (f *injectField) serviceType() (typ reflect.Type, all bool) {
+	if f.typ.Kind() == reflect.Slice {
+		return f.typ.Elem(), true
+	}
+	return f.typ, false
+}
+
+type InjectError struct {
+	Field string
+	Tag   string
+	Err   error
+}
+
+func (e InjectError) Error() string {
+	return fmt.Sprintf("Inject field %s `%s:%q`: %v", e.Field, InjectTag, e.Tag, e.Err)
+}
+
+func (e InjectError) Unwrap() error {
+	return e.Err
+}
+
+func injectFields(value interface{}) (fields []*injectField, err error) {
+	typ := reflect.TypeOf(value)
+	if typ == nil || typ.Kind() != reflect.Ptr || typ.Elem().Kind() != reflect.Struct {
+		return
+	}
+	typ = typ.Elem()
+	if cached, ok := injectFieldsOf.Load(typ); ok {
+		return cached.([]*injectField), nil
+	}
+	for i := 0; i < typ.NumField(); i++ {
+		sf := typ.Field(i)
+		tag, ok := sf.Tag.Lookup(InjectTag)
+		if !ok {
+			continue
+		}
+		f := &injectField{name: sf.Name, index: i, typ: sf.Type}
+		parts := strings.SplitN(tag, "=", 2)
+		f.kind = parts[0]
+		if len(parts) == 2 {
+			f.arg = parts[1]
+		}
+		if sf.PkgPath != "" {
+			return nil, InjectError{sf.Name, tag, fmt.Errorf("unexported field")}
+		}
+		switch f.kind {
+		case injectOption, injectPlugin:
+			if f.arg == "" {
+				return nil, InjectError{sf.Name, tag, fmt.Errorf("blank %s", f.kind)}
+			}
+		case injectService:
+		case injectLogger:
+			if !loggerType.AssignableTo(f.typ) {
+				return nil, InjectError{sf.Name, tag, fmt.Errorf("field type %s is not a logger", f.typ)}
+			}
+		default:
+			return nil, InjectError{sf.Name, tag, fmt.Errorf("invalid kind %q", f.kind)}
+		}
+		fields = append(fields, f)
+	}
+	injectFieldsOf.Store(typ, fields)
+	return
+}
+
+// Inject sets the tagged fields of plugin value.
+func (pls *Plugins) Inject(p *Plugin) (err error) {
+	var fields []*injectField
+	if fields, err = injectFields(p.Value); err != nil || len(fields) == 0 {
+		return
+	}
+	rv := reflect.ValueOf(p.Value).Elem()
+	for _, f := range fields {
+		var value interface{}
+		if value, err = pls.injectValue(p, f); err != nil {
+			return InjectError{f.name, f.kind + "=" + f.arg, err}
+		}
+		if value == nil {
+			continue
+		}
+		v := reflect.ValueOf(value)
+		if !v.Type().AssignableTo(f.typ) {
+			return InjectError{f.name, f.kind + "=" + f.arg, fmt.Errorf("value type %s is not assignable to %s", v.Type(), f.typ)}
+		}
+		rv.Field(f.index).Set(v)
+	}
+	return
+}
+
+func (pls *Plugins) injectValue(p *Plugin, f *injectField) (value interface{}, err error) {
+	switch f.kind {
+	case injectOption:
+		var ok bool
+		if value, ok, err = pls.Options().GetE(f.arg); err == nil && !ok {
+			err = fmt.Errorf("option %q does not exists", f.arg)
+		}
+	case injectService:
+		typ, all := f.serviceType()
+		if !all {
+			return pls.ResolveService(typ)
+		}
+		values := reflect.MakeSlice(f.typ, 0, 0)
+		for _, v := range pls.ResolveAllServices(typ) {
+			values = reflect.Append(values, reflect.ValueOf(v))
+		}
+		value = values.Interface()
+	case injectPlugin:
+		other := pls.ByUID.Get(f.arg)
+		if other == nil {
+			return nil, fmt.Errorf("plugin %q not registered", f.arg)
+		}
+		if f.typ == pluginType {
+			return other, nil
+		}
+		value = other.Value
+	case injectLogger:
+		value = p.Logger()
+	}
+	return
+}
+
+// sortInject adds the order edges of injected fields: after injected plugins and services providers.
+func (pls *Plugins) sortInject(providers map[reflect.Type][]string) func(state *SorterState, p *Plugin) (err error) {
+	return func(state *SorterState, p *Plugin) (err error) {
+		var fields []*injectField
+		if fields, err = injectFields(p.Value); err != nil {
+			return
+		}
+		uid := p.UID()
+		for _, f := range fields {
+			switch f.kind {
+			case injectPlugin:
+				if _, ok := state.pluginsMap[f.arg]; !ok {
+					return InjectError{f.name, f.kind + "=" + f.arg, fmt.Errorf("plugin %q not registered", f.arg)}
+				}
+				state.AddEdge(uid, f.arg)
+			case injectService:
+				typ, all := f.serviceType()
+				uids, ok := providers[typ]
+				if !ok && !all {
+					return InjectError{f.name, f.kind, fmt.Errorf("service %s does not have provider", typ)}
+				}
+				for _, providerUID := range uids {
+					if providerUID != uid {
+						state.AddEdge(uid, providerUID)
+					}
+				}
+			}
+		}
+		return
+	}
+}
@@ -0,0 +1,39 @@
+package pluggable
+
+const (
+	PhaseRegister       = "register"
+	PhaseProvideOptions = "provideOptions"
+	PhaseInit           = "init"
+)
+
+// CallInfo describes the plugin call intercepted.
+type CallInfo struct {
+	Phase  string
+	Plugin *Plugin
+}
+
+func (ci CallInfo) UID() string {
+	return ci.Plugin.UID()
+}
+
+// Interceptor is called around plugin calls. It must call next to continue the chain.
+type Interceptor func(info CallInfo, next func() error) error
+
+// Use adds interceptors called around OnRegister, ProvidesOptions and Init plugin calls. The
+// first interceptor is the outermost. Panics of plugin calls are received as *PluginPanicError.
+func (pls *Plugins) Use(interceptor ...Interceptor) {
+	pls.interceptors = append(pls.interceptors, interceptor...)
+}
+
+func (pls *Plugins) call(info CallInfo, f func() error) error {
+	next := func() error {
+		return pls.catchPanic(info.UID(), info.Phase, "", f)
+	}
+	for i := len(pls.interceptors) - 1; i >= 0; i-- {
+		interceptor, inner := pls.interceptors[i], next
+		next = func() error {
+			return interceptor(info, inner)
+		}
+	}
+	return next()
+}
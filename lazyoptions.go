@@ -0,0 +1,186 @@
+package pluggable
+
+import (
+	"fmt"
+	"strings"
+
+	errwrap "github.com/moisespsena-go/error-wrap"
+)
+
+type LazyOption func() (value interface{}, err error)
+
+type lazyValue struct {
+	key   string
+	get   LazyOption
+	done  bool
+	value interface{}
+	err   error
+	// owner is the goroutine resolving the value. wait is closed after resolution ends.
+	owner uint64
+	wait  chan struct{}
+}
+
+type OptionCycleError struct {
+	Keys []string
+}
+
+func (e OptionCycleError) Error() string {
+	return fmt.Sprintf("Lazy options cycle: %s", strings.Join(e.Keys, " -> "))
+}
+
+// SetLazy sets the value of key computed by get on first access. The result (value or error) is
+// cached, so get is called only once.
+func (o *Options) SetLazy(key string, get LazyOption) (err error) {
+	if err = o.checkMutable(key); err != nil {
+		return
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.values.Set(key, &lazyValue{key: key, get: get})
+	o.setKey(key, "")
+	return
+}
+
+// IsLazy returns if key value is lazy and not resolved yet.
+func (o *Options) IsLazy(key string) bool {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	v, _ := o.values.Get(key)
+	lv, ok := v.(*lazyValue)
+	return ok && !lv.done
+}
+
+// GetE returns the value of key, resolving it if is lazy.
+func (o *Options) GetE(key string) (value interface{}, ok bool, err error) {
+	o.mu.RLock()
+	value, ok = o.values.Get(key)
+	o.mu.RUnlock()
+	if lv, isLazy := value.(*lazyValue); isLazy {
+		value, err = o.resolve(lv)
+	}
+	return
+}
+
+// Get returns the value of key, resolving it if is lazy. If the lazy resolution fails, the error is
+// logged and returns nil and false: use GetE to get it.
+func (o *Options) Get(key string) (value interface{}, ok bool) {
+	var err error
+	if value, ok, err = o.GetE(key); err != nil {
+		log.Errorf("Option %q: %v", key, err)
+		return nil, false
+	}
+	return
+}
+
+func (o *Options) GetInterface(key string) interface{} {
+	value, _ := o.Get(key)
+	return value
+}
+
+// peek returns the value of key without resolve it. Unresolved lazy values returns nil. Must be called
+// with o.mu locked.
+func (o *Options) peek(key string) (value interface{}, ok bool) {
+	if value, ok = o.values.Get(key); ok {
+		if lv, isLazy := value.(*lazyValue); isLazy {
+			value = lv.value
+		}
+	}
+	return
+}
+
+// resolve returns the value of lv, calling their getter once. Concurrent callers waits for the
+// resolution of the first one. Cycles are detected by call chain: the lazy values being resolved by
+// each goroutine, and the lazy value that each goroutine waits for.
+func (o *Options) resolve(lv *lazyValue) (value interface{}, err error) {
+	gid := goroutineID()
+	o.mu.Lock()
+	if o.resolving == nil {
+		o.resolving, o.waiting = map[uint64][]*lazyValue{}, map[uint64]*lazyValue{}
+	}
+	for lv.wait != nil {
+		if keys := o.lazyCycle(gid, lv); keys != nil {
+			o.mu.Unlock()
+			return nil, OptionCycleError{keys}
+		}
+		wait := lv.wait
+		o.waiting[gid] = lv
+		o.mu.Unlock()
+		<-wait
+		o.mu.Lock()
+		delete(o.waiting, gid)
+	}
+	if lv.done {
+		o.mu.Unlock()
+		return lv.value, lv.err
+	}
+	lv.owner, lv.wait = gid, make(chan struct{})
+	o.resolving[gid] = append(o.resolving[gid], lv)
+	o.mu.Unlock()
+
+	defer func() {
+		o.mu.Lock()
+		defer o.mu.Unlock()
+		if stack := o.resolving[gid]; len(stack) > 1 {
+			o.resolving[gid] = stack[:len(stack)-1]
+		} else {
+			delete(o.resolving, gid)
+		}
+		close(lv.wait)
+		lv.wait = nil
+	}()
+
+	value, err = lv.get()
+
+	if err != nil {
+		if _, ok := err.(OptionCycleError); ok {
+			// does not cache: the value may be resolved by other call chain
+			return nil, err
+		}
+		err = errwrap.Wrap(err, "Lazy option %q", lv.key)
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	lv.done, lv.value, lv.err = true, value, err
+	if err == nil {
+		if current, _ := o.values.Get(lv.key); current == lv {
+			o.values.Set(lv.key, value)
+		}
+	}
+	return
+}
+
+// lazyCycle returns the keys of cycle if goroutine gid waits for lv, otherwise nil. Must be called
+// with o.mu locked.
+func (o *Options) lazyCycle(gid uint64, lv *lazyValue) (keys []string) {
+	// goroutines checks cycles before wait, so the wait chain ends or comes back to gid
+	for cur := lv; cur != nil; cur = o.waiting[cur.owner] {
+		stack := o.resolving[cur.owner]
+		for i, v := range stack {
+			if v == cur {
+				for _, v := range stack[i:] {
+					keys = append(keys, v.key)
+				}
+				break
+			}
+		}
+		if cur.owner == gid {
+			return append(keys, lv.key)
+		}
+	}
+	return nil
+}
+
+// peekLazy returns the value of key without resolve it and if it is an unresolved lazy value.
+func (o *Options) peekLazy(key string) (value interface{}, ok, lazy bool) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	if value, ok = o.values.Get(key); ok {
+		if lv, isLazy := value.(*lazyValue); isLazy {
+			if lazy = !lv.done; !lazy {
+				value = lv.value
+			}
+		}
+	}
+	return
+}
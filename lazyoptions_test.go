@@ -0,0 +1,149 @@
+package pluggable
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLazyOptionResolvedOnce(t *testing.T) {
+	o := NewOptions()
+	var calls int32
+	o.SetLazy("a", func() (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(10 * time.Millisecond)
+		return 1, nil
+	})
+	if !o.IsLazy("a") {
+		t.Fatal("a is not lazy")
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, ok, err := o.GetE("a"); err != nil || !ok || v != 1 {
+				t.Errorf("GetE = %v, %v, %v", v, ok, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+	if o.IsLazy("a") {
+		t.Error("a is lazy after resolution")
+	}
+}
+
+func TestLazyOptionError(t *testing.T) {
+	o := NewOptions()
+	var calls int
+	fail := errors.New("fail")
+	o.SetLazy("a", func() (interface{}, error) {
+		calls++
+		return nil, fail
+	})
+	for i := 0; i < 2; i++ {
+		if _, _, err := o.GetE("a"); err == nil {
+			t.Fatal("expected error")
+		}
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1: the error must be cached", calls)
+	}
+	if v, ok := o.Get("a"); v != nil || ok {
+		t.Errorf("Get = %v, %v, want nil, false", v, ok)
+	}
+}
+
+func TestLazyOptionDependency(t *testing.T) {
+	o := NewOptions()
+	o.SetLazy("a", func() (interface{}, error) {
+		b, _, err := o.GetE("b")
+		if err != nil {
+			return nil, err
+		}
+		return b.(int) + 1, nil
+	})
+	o.SetLazy("b", func() (interface{}, error) {
+		return 1, nil
+	})
+	if v, _, err := o.GetE("a"); err != nil || v != 2 {
+		t.Errorf("GetE = %v, %v", v, err)
+	}
+}
+
+func TestLazyOptionCycle(t *testing.T) {
+	o := NewOptions()
+	get := func(key string) LazyOption {
+		return func() (interface{}, error) {
+			_, _, err := o.GetE(key)
+			return nil, err
+		}
+	}
+	o.SetLazy("a", get("b"))
+	o.SetLazy("b", get("c"))
+	o.SetLazy("c", get("a"))
+
+	_, _, err := o.GetE("a")
+	var cycle OptionCycleError
+	if !errors.As(err, &cycle) {
+		t.Fatalf("expected OptionCycleError, got %v", err)
+	}
+	if want := []string{"a", "b", "c", "a"}; !reflect.DeepEqual(cycle.Keys, want) {
+		t.Errorf("cycle keys = %v, want %v", cycle.Keys, want)
+	}
+	if !o.IsLazy("a") {
+		t.Error("cycle error must not be cached")
+	}
+}
+
+func TestLazyOptionCycleBetweenGoroutines(t *testing.T) {
+	o := NewOptions()
+	var started sync.WaitGroup
+	started.Add(2)
+	get := func(key string) LazyOption {
+		var once sync.Once
+		return func() (interface{}, error) {
+			// the getter is called again after the cycle error, which is not cached
+			once.Do(started.Done)
+			started.Wait()
+			_, _, err := o.GetE(key)
+			return nil, err
+		}
+	}
+	o.SetLazy("a", get("b"))
+	o.SetLazy("b", get("a"))
+
+	errs := make([]error, 2)
+	var wg sync.WaitGroup
+	for i, key := range []string{"a", "b"} {
+		wg.Add(1)
+		go func(i int, key string) {
+			defer wg.Done()
+			_, _, errs[i] = o.GetE(key)
+		}(i, key)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("deadlock")
+	}
+
+	var cycle OptionCycleError
+	if !errors.As(errs[0], &cycle) && !errors.As(errs[1], &cycle) {
+		t.Errorf("expected OptionCycleError, got %v and %v", errs[0], errs[1])
+	}
+}
@@ -8,7 +8,11 @@ import (
 	"github.com/moisespsena-go/path-helpers"
 )
 
-var E_LOCALE_FS = PKG + ".localeFS"
+var (
+	E_LOCALE_FS = PKG + ".localeFS"
+	// H_LOCALE_FS is the handler ID of plugin locale FS registration
+	H_LOCALE_FS = PKG + ".localeFS"
+)
 
 type I18nPluginsInterface interface {
 	PluginFSInterface
@@ -25,7 +29,7 @@ func (pls *I18nPlugins) LocaleFS() assetfsapi.Interface {
 }
 
 func InitPluginI18nFS(pls I18nPluginsInterface) {
-	pls.OnPlugin("register", func(e PluginEventInterface) error {
+	pls.OnPluginWithOptions("register", func(e PluginEventInterface) error {
 		p := e.Plugin()
 		fs := pls.Dispatcher().(I18nPluginsInterface).LocaleFS()
 		if registrator, ok := fs.(assetfsapi.PathRegistrator); ok && p.AbsPath != "" {
@@ -43,7 +47,7 @@ func InitPluginI18nFS(pls I18nPluginsInterface) {
 			}
 		}
 		return nil
-	})
+	}, HandlerID(H_LOCALE_FS), After(H_FS))
 }
 
 func NewI18nPlugins(fs assetfsapi.Interface, nameSpace string) *I18nPlugins {
@@ -66,8 +70,15 @@ func (e *LocaleFSEvent) RegisterWithNameSpace(nameSpace, basePath string) error
 	return nil
 }
 
+// OnLocaleFS registers the callback of E_LOCALE_FS event. Events of other type are returned as
+// error.
 func OnLocaleFS(p EventDispatcherInterface, cb func(e *LocaleFSEvent)) {
-	p.On(E_LOCALE_FS, func(e PluginEventInterface) {
-		cb(e.(*LocaleFSEvent))
+	p.On(E_LOCALE_FS, func(e PluginEventInterface) error {
+		le, ok := e.(*LocaleFSEvent)
+		if !ok {
+			return eventTypeMismatch(E_LOCALE_FS, e, le)
+		}
+		cb(le)
+		return nil
 	})
 }
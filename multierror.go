@@ -0,0 +1,68 @@
+package pluggable
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PluginError is the error of plugin (and callback, if any) collected by EachPlugins and
+// EachPluginsCallback in collect errors mode.
+type PluginError struct {
+	UID      string
+	Callback string
+	Err      error
+}
+
+func (e *PluginError) Error() string {
+	if e.Callback != "" {
+		return fmt.Sprintf("Plugin %s: Callback %s: %v", e.UID, e.Callback, e.Err)
+	}
+	return fmt.Sprintf("Plugin %s: %v", e.UID, e.Err)
+}
+
+func (e *PluginError) Unwrap() error {
+	return e.Err
+}
+
+// MultiError is the list of collected plugins errors. It supports errors.Is and errors.As.
+type MultiError struct {
+	Errors []*PluginError
+}
+
+func (e *MultiError) Error() string {
+	lines := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		lines[i] = err.Error()
+	}
+	return fmt.Sprintf("%d plugins errors:\n%s", len(e.Errors), strings.Join(lines, "\n"))
+}
+
+func (e *MultiError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, err := range e.Errors {
+		errs[i] = err
+	}
+	return errs
+}
+
+func (e *MultiError) add(err *PluginError) {
+	e.Errors = append(e.Errors, err)
+}
+
+// err returns the MultiError if it has errors, otherwise nil.
+func (e *MultiError) err() error {
+	if len(e.Errors) == 0 {
+		return nil
+	}
+	return e
+}
+
+// SetCollectErrors enables the mode where EachPlugins and EachPluginsCallback (and TriggerPlugins)
+// calls all plugins and callbacks and returns a *MultiError, instead of stopping at the first error.
+func (ped *PluginEventDispatcher) SetCollectErrors(collect bool) {
+	ped.collectErrors = collect
+}
+
+func (ped *PluginEventDispatcher) CollectErrors() bool {
+	return ped.collectErrors
+}
@@ -0,0 +1,68 @@
+package pluggable
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestMultiError(t *testing.T) {
+	var errs MultiError
+	if errs.err() != nil {
+		t.Fatal("empty MultiError is not nil error")
+	}
+
+	fail := errors.New("fail")
+	errs.add(&PluginError{UID: "a", Err: fail})
+	errs.add(&PluginError{UID: "b", Callback: "cb", Err: &PluginCancelledError{"b", "vetoed"}})
+
+	err := errs.err()
+	if err == nil {
+		t.Fatal("MultiError with errors is nil error")
+	}
+	if !errors.Is(err, fail) {
+		t.Error("errors.Is does not finds the plugin error")
+	}
+	var cancelled *PluginCancelledError
+	if !errors.As(err, &cancelled) || cancelled.UID != "b" {
+		t.Error("errors.As does not finds the cancelled error")
+	}
+	var pluginErr *PluginError
+	if !errors.As(err, &pluginErr) || pluginErr.UID != "a" {
+		t.Error("errors.As does not finds the first plugin error")
+	}
+
+	msg := err.Error()
+	for _, want := range []string{"2 plugins errors", "Plugin a: fail", "Plugin b: Callback cb:"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("message %q does not contains %q", msg, want)
+		}
+	}
+}
+
+type multiErrorPluginA struct{}
+type multiErrorPluginB struct{}
+
+func TestTriggerPluginsCollectErrors(t *testing.T) {
+	pls := NewPlugins()
+	if err := pls.Add(&multiErrorPluginA{}, &multiErrorPluginB{}); err != nil {
+		t.Fatal(err)
+	}
+	pls.SetCollectErrors(true)
+	fail := errors.New("fail")
+	pls.OnPlugin("test", func(e PluginEventInterface) error {
+		return fail
+	})
+
+	err := pls.TriggerPlugins(NewEvent("test"))
+	var multi *MultiError
+	if !errors.As(err, &multi) {
+		t.Fatalf("expected *MultiError, got %v", err)
+	}
+	if len(multi.Errors) != 2 {
+		t.Errorf("errors = %d, want 2", len(multi.Errors))
+	}
+	if !errors.Is(err, fail) {
+		t.Error("errors.Is does not finds the callback error")
+	}
+}
@@ -1,13 +1,244 @@
 package pluggable
 
 import (
+	"fmt"
+	"strings"
+	"sync"
+
 	"github.com/moisespsena-go/options"
 )
 
+type OptionFrozenError struct {
+	Key string
+}
+
+func (e OptionFrozenError) Error() string {
+	return fmt.Sprintf("Option %q is frozen", e.Key)
+}
+
+func (e OptionFrozenError) Is(err error) bool {
+	return err == OptionsFrozen
+}
+
+type OptionWatcher func(old, new interface{})
+
 type Options struct {
-	options.Options
+	// values are not exported: their accessors are not synchronized, does not checks Freeze and returns
+	// the unresolved lazy values
+	values   options.Options
+	frozen   bool
+	strict   bool
+	mutable  map[string]bool
+	watchers map[string][]OptionWatcher
+	// resolving are the lazy values being resolved by goroutine ID, and waiting the lazy value that
+	// each goroutine waits for.
+	resolving map[uint64][]*lazyValue
+	waiting   map[uint64]*lazyValue
+	// secretRefs are the keys setted by SetSecretRef and not resolved yet
+	secretRefs []string
+	// resolveSecret is setted by Plugins.ProvideOptions to resolve the references setted after it
+	resolveSecret func(key string, ref SecretRef) error
+	keys          []string
+	sources       map[string]string
+	// source is the source of values setted by Set and SetLazy
+	source string
+	mu     sync.RWMutex
 }
 
+// Options values sources. This package does not load config files nor environment variables: their
+// loaders must set the values by SetFrom with OptionSourceFile or OptionSourceEnv, otherwise the
+// values are reported as OptionSourceRuntime. Secret values have their secret source name.
+const (
+	// OptionSourceDefault is the source of NewOptions data
+	OptionSourceDefault = "default"
+	// OptionSourceProvider is the source of values setted by plugins in ProvideOptions
+	OptionSourceProvider = "provider"
+	// OptionSourceRuntime is the source of values setted by Set out of ProvideOptions, like Reconfigure
+	OptionSourceRuntime = "runtime"
+	OptionSourceFile    = "file"
+	OptionSourceEnv     = "env"
+)
+
 func NewOptions(data ...map[string]interface{}) *Options {
-	return &Options{options.NewOptions(data...)}
+	o := &Options{values: options.NewOptions(data...)}
+	for _, d := range data {
+		for key := range d {
+			o.setKey(key, OptionSourceDefault)
+		}
+	}
+	return o
+}
+
+// setKey registers the key and the source of their value. If source is blank, uses the current source
+// (see withSource) or OptionSourceRuntime.
+func (o *Options) setKey(key, source string) {
+	if o.sources == nil {
+		o.sources = map[string]string{}
+	}
+	if _, ok := o.sources[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	if source == "" {
+		if source = o.source; source == "" {
+			source = OptionSourceRuntime
+		}
+	}
+	o.sources[key] = source
+}
+
+// withSource sets the source of values setted by Set and SetLazy, and returns the restore function.
+func (o *Options) withSource(source string) func() {
+	o.mu.Lock()
+	old := o.source
+	o.source = source
+	o.mu.Unlock()
+	return func() {
+		o.mu.Lock()
+		o.source = old
+		o.mu.Unlock()
+	}
+}
+
+func (o *Options) delKey(key string) {
+	if _, ok := o.sources[key]; !ok {
+		return
+	}
+	delete(o.sources, key)
+	for i, k := range o.keys {
+		if k == key {
+			o.keys = append(o.keys[:i], o.keys[i+1:]...)
+			break
+		}
+	}
+}
+
+// Keys returns the keys setted by this Options methods, in insertion order.
+func (o *Options) Keys() []string {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return append([]string{}, o.keys...)
+}
+
+// Source returns where the key value comes from.
+func (o *Options) Source(key string) string {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return o.sources[key]
+}
+
+// Freeze makes the options read only. After it, Set and Del returns OptionFrozenError for
+// keys not allowed by Mutable, except internal keys. In strict mode, they panics.
+func (o *Options) Freeze() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.frozen = true
+}
+
+func (o *Options) Frozen() bool {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return o.frozen
+}
+
+// SetStrict enables panic on write into frozen options.
+func (o *Options) SetStrict(strict bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.strict = strict
+}
+
+// Mutable allows keys to be changed after Freeze. Use it for runtime-tunable settings.
+func (o *Options) Mutable(key ...string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.mutable == nil {
+		o.mutable = map[string]bool{}
+	}
+	for _, k := range key {
+		o.mutable[k] = true
+	}
+}
+
+// IsMutable returns if key can be changed. The internal keys (prefixed by PKG) are always mutable.
+func (o *Options) IsMutable(key string) bool {
+	if strings.HasPrefix(key, PKG+".") {
+		return true
+	}
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return !o.frozen || o.mutable[key]
+}
+
+func (o *Options) checkMutable(key string) (err error) {
+	if o.IsMutable(key) {
+		return nil
+	}
+	err = OptionFrozenError{key}
+	o.mu.RLock()
+	strict := o.strict
+	o.mu.RUnlock()
+	if strict {
+		panic(err)
+	}
+	return
+}
+
+// Has returns if key exists. Unlike the embedded Options methods, it is safe for use concurrently
+// with Set, Del and Reconfigure.
+func (o *Options) Has(key string) bool {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return o.values.Has(key)
+}
+
+// Watch registers the callback called after key value changes. On Del, new value is nil.
+func (o *Options) Watch(key string, cb OptionWatcher) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.watchers == nil {
+		o.watchers = map[string][]OptionWatcher{}
+	}
+	o.watchers[key] = append(o.watchers[key], cb)
+}
+
+func (o *Options) notify(key string, old, new interface{}) {
+	o.mu.RLock()
+	watchers := o.watchers[key]
+	o.mu.RUnlock()
+	for _, cb := range watchers {
+		cb(old, new)
+	}
+}
+
+func (o *Options) Set(key string, value interface{}) (err error) {
+	return o.SetFrom("", key, value)
+}
+
+// SetFrom sets the key value comes from source.
+func (o *Options) SetFrom(source, key string, value interface{}) (err error) {
+	if err = o.checkMutable(key); err != nil {
+		return
+	}
+	o.mu.Lock()
+	old, _ := o.peek(key)
+	o.values.Set(key, value)
+	o.setKey(key, source)
+	o.mu.Unlock()
+	o.notify(key, old, value)
+	return
+}
+
+func (o *Options) Del(key string) (err error) {
+	if err = o.checkMutable(key); err != nil {
+		return
+	}
+	o.mu.Lock()
+	old, ok := o.peek(key)
+	o.values.Del(key)
+	o.delKey(key)
+	o.mu.Unlock()
+	if ok {
+		o.notify(key, old, nil)
+	}
+	return
 }
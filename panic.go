@@ -0,0 +1,54 @@
+package pluggable
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+const PhaseEvent = "event"
+
+// PluginPanicError is the error of recovered panic in plugin call or event handler. The Error message
+// does not contains the Stack, so it is safe to expose.
+type PluginPanicError struct {
+	UID   string
+	Phase string
+	Event string
+	Value interface{}
+	Stack []byte
+}
+
+func (e *PluginPanicError) Error() string {
+	msg := fmt.Sprintf("Plugin %q panics on %s", e.UID, e.Phase)
+	if e.Event != "" {
+		msg += fmt.Sprintf(" (event %q)", e.Event)
+	}
+	return msg + fmt.Sprintf(": %v", e.Value)
+}
+
+// Unwrap returns the panic value if it is an error.
+func (e *PluginPanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// SetPanicRecovery enables or disables (useful for development) the panic recovery of plugin calls
+// and event handlers. It is enabled by default.
+func (ped *PluginEventDispatcher) SetPanicRecovery(enabled bool) {
+	ped.noRecover = !enabled
+}
+
+func (ped *PluginEventDispatcher) PanicRecovery() bool {
+	return !ped.noRecover
+}
+
+func (ped *PluginEventDispatcher) catchPanic(uid, phase, event string, f func() error) (err error) {
+	if ped.noRecover {
+		return f()
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PluginPanicError{uid, phase, event, r, debug.Stack()}
+		}
+	}()
+	return f()
+}
@@ -0,0 +1,120 @@
+package pluggable
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/moisespsena-go/edis"
+)
+
+// IsEventPattern reports if the event name is a pattern. Patterns supports `*` (any sequence of
+// characters, including `/`) and `?` (any single character). The EAll name is not a pattern.
+func IsEventPattern(name string) bool {
+	return name != EAll && strings.ContainsAny(name, "*?")
+}
+
+// MatchEventPattern reports if name matches the pattern.
+func MatchEventPattern(pattern, name string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 0 && pattern[0] == '*' {
+				pattern = pattern[1:]
+			}
+			if pattern == "" {
+				return true
+			}
+			for i := 0; i <= len(name); i++ {
+				if MatchEventPattern(pattern, name[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if name == "" {
+				return false
+			}
+		default:
+			if name == "" || name[0] != pattern[0] {
+				return false
+			}
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return name == ""
+}
+
+type patternCallback struct {
+	pattern string
+	cb      interface{}
+}
+
+func callCallback(cb interface{}, e EventInterface) error {
+	switch t := cb.(type) {
+	case interface{ Call(e EventInterface) error }:
+		return t.Call(e)
+	case edis.CallbackFuncE:
+		return t(e)
+	case edis.CallbackFunc:
+		t(e)
+	case func(e EventInterface) error:
+		return t(e)
+	case func(e EventInterface):
+		t(e)
+	}
+	return nil
+}
+
+func (ed *EventDispatcher) onPattern(pattern string, callbacks []interface{}) error {
+	for _, cb := range callbacks {
+		switch cb.(type) {
+		case interface{ Call(e EventInterface) error }, edis.CallbackFuncE, edis.CallbackFunc,
+			func(e EventInterface) error, func(e EventInterface):
+		default:
+			return fmt.Errorf("Invalid Callback type %T", cb)
+		}
+	}
+	ed.patternsMu.Lock()
+	defer ed.patternsMu.Unlock()
+	for _, cb := range callbacks {
+		ed.patterns = append(ed.patterns, patternCallback{pattern, cb})
+	}
+	return nil
+}
+
+func (ed *EventDispatcher) triggerPatterns(e EventInterface) (err error) {
+	ed.patternsMu.RLock()
+	patterns := ed.patterns
+	ed.patternsMu.RUnlock()
+	for _, p := range patterns {
+		if MatchEventPattern(p.pattern, e.Name()) {
+			if err = callCallback(p.cb, e); err != nil {
+				return
+			}
+			if pe, ok := e.(PluginEventInterface); ok && pe.PropagationStopped() {
+				return
+			}
+		}
+	}
+	return
+}
+
+// callPatternHandlers calls the handlers registered by OnPlugin with pattern matching eventName.
+func (ped *PluginEventDispatcher) callPatternHandlers(eventName string, e PluginEventInterface) (err error) {
+	ped.handlersMu.RLock()
+	var patterns []string
+	for pattern := range ped.handlers {
+		if IsEventPattern(pattern) && MatchEventPattern(pattern, eventName) {
+			patterns = append(patterns, pattern)
+		}
+	}
+	ped.handlersMu.RUnlock()
+	sort.Strings(patterns)
+	for _, pattern := range patterns {
+		if err = ped.callHandlers(pattern, e); err != nil || e.PropagationStopped() {
+			return
+		}
+	}
+	return
+}
@@ -0,0 +1,52 @@
+package pluggable
+
+import "testing"
+
+func TestMatchEventPattern(t *testing.T) {
+	tests := []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"init", "init", true},
+		{"init", "initDone", false},
+		{"*", "", true},
+		{"*", "plugin:init", true},
+		{"plugin:*", "plugin:init", true},
+		{"plugin:*", "plugin:", true},
+		{"plugin:*", "init", false},
+		{"*Done", "initDone", true},
+		{"*Done", "initDone2", false},
+		{"a*b*c", "axxbyyc", true},
+		{"a*b*c", "axxbyy", false},
+		{"a**c", "abc", true},
+		{"pluggable.*", "pluggable.FS/x", true},
+		{"?", "a", true},
+		{"?", "", false},
+		{"?", "ab", false},
+		{"init?", "inits", true},
+		{"*?", "", false},
+		{"*?", "x", true},
+		{"", "", true},
+		{"", "a", false},
+	}
+	for _, tt := range tests {
+		if got := MatchEventPattern(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("MatchEventPattern(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIsEventPattern(t *testing.T) {
+	tests := map[string]bool{
+		"init":      false,
+		"plugin:*":  true,
+		"init?":     true,
+		EAll:        false,
+		"pluggable": false,
+	}
+	for name, want := range tests {
+		if got := IsEventPattern(name); got != want {
+			t.Errorf("IsEventPattern(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
@@ -1,9 +1,11 @@
 package pluggable
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 	"sync"
+	"sync/atomic"
 
 	"github.com/go-errors/errors"
 	defaultlogger "github.com/moisespsena-go/default-logger"
@@ -20,6 +22,8 @@ const (
 	E_INIT_PLUGINS = "initPlugins"
 	E_INIT_DONE    = "initDone"
 	E_POST_INIT    = "postInit"
+
+	E_OPTIONS_CHANGED = "optionsChanged"
 )
 
 var eof = errors.New("!eof")
@@ -96,40 +100,72 @@ type Plugins struct {
 	ByUID           PluginsMap
 	Extensions      []Extension
 	initialized     bool
+	initDone        atomic.Bool
+	postInitDone    bool
 	plugins         []*Plugin
 	sorted          bool
 	optionsProvider map[string]*Plugin
 	befores         map[string][]string
 	afters          map[string][]string
+	freezeOptions   bool
+	mutableOptions  []string
+	secretSources   map[string]SecretSource
+	services        map[reflect.Type][]serviceEntry
+	servicesMu      sync.RWMutex
+	// current is the plugin in registration or initialization
+	current        atomic.Pointer[Plugin]
+	implementing   map[reflect.Type][]*Plugin
+	implementingMu sync.RWMutex
+
+	extensionPoints        map[string]*extensionPoint
+	contributions          []*contribution
+	contributionsValidated bool
+	extensionsMu           sync.RWMutex
+
+	interceptors []Interceptor
+
+	deps            map[string][]string
+	continueOnError bool
+	failed          map[string]error
+	bootReport      BootReport
+	ctx             context.Context
 }
 
 func NewPlugins() *Plugins {
 	p := &Plugins{}
-	p.PluginEventDispatcher.PluginsGetter = func() []*Plugin {
-		return p.plugins
-	}
+	p.PluginEventDispatcher.PluginsGetter = p.activePlugins
 	p.SetDispatcher(p)
 	p.SetOptions(NewOptions())
 	if p.log == nil {
 		p.log = log
 	}
+	p.OnPlugin(E_OPTIONS_CHANGED, reconfigurePlugin)
+	p.SecretSource("env", EnvSecretSource{})
+	p.SecretSource("file", FileSecretSource{})
 	return p
 }
 
+// FreezeOptionsOnInit freezes the global options at end of Init. The mutableKeys
+// still can be changed at runtime.
+func (pls *Plugins) FreezeOptionsOnInit(mutableKeys ...string) {
+	pls.freezeOptions = true
+	pls.mutableOptions = append(pls.mutableOptions, mutableKeys...)
+}
+
+// Extension adds the extensions. After Init, each extension is initialized and added only if it
+// succeeds: the next ones are not added.
 func (pls *Plugins) Extension(extensions ...Extension) (err error) {
-	pls.Extensions = append(pls.Extensions, extensions...)
-	if pls.initialized {
-		for _, extension := range extensions {
-			extension.Init(pls)
-			if ed, ok := extension.(edis.EventDispatcherInterface); ok {
-				err = pls.Each(func(plugin *Plugin) (err error) {
-					return ed.Trigger(edis.NewEvent("pluginRegister", plugin))
-				})
-				if err != nil {
-					return errwrap.Wrap(err, "Extension %v", extension)
-				}
-			}
+	if !pls.initialized {
+		pls.Extensions = append(pls.Extensions, extensions...)
+		pls.sortExtensions()
+		return
+	}
+	for _, extension := range extensions {
+		if err = pls.lateExtension(extension); err != nil {
+			return errwrap.Wrap(err, "Extension %T", extension)
 		}
+		pls.Extensions = append(pls.Extensions, extension)
+		pls.sortExtensions()
 	}
 	return
 }
@@ -139,8 +175,8 @@ func (pls *Plugins) TriggerPlugins(e edis.EventInterface, plugins ...*Plugin) (e
 	log.Debug("start")
 	defer log.Debug("done")
 	if len(plugins) == 0 {
-		if len(pls.plugins) > 0 {
-			return pls.PluginEventDispatcher.TriggerPlugins(e, pls.plugins...)
+		if plugins = pls.activePlugins(); len(plugins) > 0 {
+			return pls.PluginEventDispatcher.TriggerPlugins(e, plugins...)
 		}
 		return nil
 	}
@@ -151,19 +187,24 @@ func (pls *Plugins) Each(cb func(plugin *Plugin) (err error)) (err error) {
 	if !pls.initialized {
 		return fmt.Errorf("Plugins has not be initialized")
 	}
-	return pls.EachPlugins(pls.plugins, cb)
+	return pls.EachPlugins(pls.activePlugins(), cb)
 }
 
 func (pls *Plugins) Add(plugin ...interface{}) (err error) {
 	return pls.AddTo(&pls.plugins, plugin...)
 }
 
+// AddTo registers the plugins into to. The plugins which registration fails or is cancelled by the
+// "register" event (*PluginCancelledError) are removed, and the errors are returned as *MultiError after
+// register the others. After Init, the plugins are initialized too: in continue on error mode, the
+// plugins which Init fails are marked as failed instead of removed.
 func (pls *Plugins) AddTo(to *[]*Plugin, plugin ...interface{}) (err error) {
 	var (
 		pi                interface{}
 		rvalue            reflect.Value
 		pth, absPath, uid string
 		p                 *Plugin
+		errs              MultiError
 	)
 
 	for _, pi = range plugin {
@@ -195,13 +236,11 @@ func (pls *Plugins) AddTo(to *[]*Plugin, plugin ...interface{}) (err error) {
 		}
 
 		err = func() (err error) {
-			defer func() {
-				if err != nil {
-					err = errwrap.Wrap(err, "Plugin %q", p.UID())
-				}
-			}()
 			*to = append(*to, p)
 			pls.ByUID.Add(p)
+			pls.resetImplementing()
+
+			defer pls.withCurrent(p)()
 
 			if setter, ok := pi.(PluginSetter); ok {
 				setter.SetPlugin(p)
@@ -211,24 +250,80 @@ func (pls *Plugins) AddTo(to *[]*Plugin, plugin ...interface{}) (err error) {
 				setter.SetLogger(p.Logger())
 			}
 
-			switch r := pi.(type) {
-			case PluginRegister:
-				r.OnRegister()
-			case PluginRegisterArg:
-				r.OnRegister(p)
-			case PluginRegisterOptionsArg:
-				r.OnRegister(pls.options)
+			if err = pls.call(CallInfo{PhaseRegister, p}, func() error {
+				switch r := pi.(type) {
+				case PluginRegister:
+					r.OnRegister()
+				case PluginRegisterArg:
+					r.OnRegister(p)
+				case PluginRegisterOptionsArg:
+					r.OnRegister(pls.options)
+				}
+				return nil
+			}); err != nil {
+				return
 			}
 
-			err = pls.TriggerPlugins(edis.NewEvent(E_REGISTER), p)
+			registerEvent := NewPluginEvent(E_REGISTER)
+			if err = pls.TriggerPlugins(registerEvent, p); err != nil {
+				return
+			}
+			if registerEvent.Cancelled() {
+				log.Warningf("%q registration cancelled: %s", p.UID(), registerEvent.CancelReason())
+				return &PluginCancelledError{p.UID(), registerEvent.CancelReason()}
+			}
+
+			if err = pls.extensionsPluginAdded(p); err != nil {
+				return
+			}
 
 			if pls.initialized {
-				err = pls.initPlugin(p)
+				if err = pls.Inject(p); err == nil {
+					err = pls.initPlugin(p)
+				}
+				if err != nil {
+					if !pls.continueOnError {
+						return
+					}
+					pls.markFailed(p, err)
+					return nil
+				}
+				if pls.postInitDone {
+					pls.addStickyPlugin(E_POST_INIT, p)
+					err = pls.TriggerPlugins(edis.NewEvent(E_POST_INIT), p)
+				}
 			}
 			return
 		}()
+		if err != nil {
+			pls.remove(to, p)
+			errs.add(&PluginError{UID: uid, Err: err})
+			err = nil
+		}
+	}
+	return errs.err()
+}
+
+// remove removes the plugin from to and registered plugins, and the services, extension points and
+// contributions registered by it.
+func (pls *Plugins) remove(to *[]*Plugin, p *Plugin) {
+	for i, other := range *to {
+		if other == p {
+			*to = append((*to)[:i], (*to)[i+1:]...)
+			break
+		}
+	}
+	delete(pls.ByUID, p.UID())
+	pls.resetImplementing()
+	pls.removeServices(p)
+	pls.removeExtensions(p)
+}
+
+func (pls *Plugins) withCurrent(p *Plugin) func() {
+	old := pls.current.Swap(p)
+	return func() {
+		pls.current.Store(old)
 	}
-	return nil
 }
 
 func (pls *Plugins) doPlugin(p *Plugin, f func(p *Plugin) (err error)) (err error) {
@@ -307,7 +402,15 @@ func (pls *Plugins) sortProviders() (providers []*Plugin, err error) {
 					for _, optionName := range provides.ProvideOptions() {
 						// if have previous provider, order it
 						if prevId, ok := provider[optionName]; ok {
-							state.Graph.AddEdge(uid, prevId)
+							state.AddEdge(uid, prevId)
+						}
+						provider[optionName] = uid
+					}
+				}
+				if provides, ok := p.Value.(LazyOptionProvider); ok {
+					for optionName := range provides.LazyOptions() {
+						if prevId, ok := provider[optionName]; ok {
+							state.AddEdge(uid, prevId)
 						}
 						provider[optionName] = uid
 					}
@@ -316,6 +419,9 @@ func (pls *Plugins) sortProviders() (providers []*Plugin, err error) {
 			return nil
 		},
 		Post: func(state *SorterState) error {
+			if pls.optionsProvider == nil {
+				pls.optionsProvider = map[string]*Plugin{}
+			}
 			for optionName, uid := range provider {
 				pls.optionsProvider[optionName] = state.pluginsMap[uid]
 			}
@@ -330,12 +436,12 @@ func (pls *Plugins) sortProviders() (providers []*Plugin, err error) {
 					if optionName == "" {
 						panic(fmt.Errorf("empty option name from %s (%T)", uid, p.Value))
 					}
-					if _, ok := pls.options.Get(optionName); !ok {
+					if !pls.options.Has(optionName) {
 						providedBy, ok := provider[optionName]
 						if !ok {
 							return fmt.Errorf("Option %q, required by %s, does not have provedor.", optionName, p)
 						}
-						state.Graph.AddEdge(uid, providedBy)
+						state.AddEdge(uid, providedBy)
 					}
 				}
 				return nil
@@ -356,54 +462,67 @@ func (pls *Plugins) ProvideOptions() (err error) {
 	if providers, err = pls.sortProviders(); err != nil {
 		return
 	}
+	defer pls.options.withSource(OptionSourceProvider)()
 	for _, p := range providers {
-		switch provider := p.Value.(type) {
-		case OptionProvider:
-			provider.ProvidesOptions(pls.options)
-		case OptionProviderE:
-			if err = provider.ProvidesOptions(pls.options); err != nil {
-				return errwrap.Wrap(err, "Plugin {%v} Provides failed", p.String())
+		if err = pls.call(CallInfo{PhaseProvideOptions, p}, func() (err error) {
+			switch provider := p.Value.(type) {
+			case OptionProvider:
+				provider.ProvidesOptions(pls.options)
+			case OptionProviderE:
+				if err = provider.ProvidesOptions(pls.options); err != nil {
+					return
+				}
+			}
+			if provider, ok := p.Value.(LazyOptionProvider); ok {
+				for optionName, get := range provider.LazyOptions() {
+					if err = pls.options.SetLazy(optionName, get); err != nil {
+						return
+					}
+				}
 			}
+			return
+		}); err != nil {
+			return errwrap.Wrap(err, "Plugin {%v} Provides failed", p.String())
 		}
 	}
-	return
+	return pls.resolveSecrets()
 }
 
 func (pls *Plugins) sortf(state *SorterState, p *Plugin) (err error) {
-	graph, uidOrPanic := state.Graph, state.UidOrPanic
+	addEdge, uidOrPanic := state.AddEdge, state.UidOrPanic
 	if after, ok := p.Value.(PluginAfterUID); ok {
 		for _, v := range after.After() {
-			graph.AddEdge(p.UID(), uidOrPanic(v))
+			addEdge(p.UID(), uidOrPanic(v))
 		}
 	}
 
 	if after, ok := p.Value.(PluginAfterI); ok {
 		for _, v := range after.After() {
-			graph.AddEdge(p.UID(), uidOrPanic(v))
+			addEdge(p.UID(), uidOrPanic(v))
 		}
 	}
 
 	if after, ok := state.Afters[p.UID()]; ok {
 		for _, v := range after {
-			graph.AddEdge(p.UID(), uidOrPanic(v))
+			addEdge(p.UID(), uidOrPanic(v))
 		}
 	}
 
 	if before, ok := p.Value.(PluginBeforeUID); ok {
 		for _, v := range before.Before() {
-			graph.AddEdge(uidOrPanic(v), p.UID())
+			addEdge(uidOrPanic(v), p.UID())
 		}
 	}
 
 	if before, ok := p.Value.(PluginBeforeI); ok {
 		for _, v := range before.Before() {
-			graph.AddEdge(uidOrPanic(v), p.UID())
+			addEdge(uidOrPanic(v), p.UID())
 		}
 	}
 
 	if before, ok := state.Befors[p.UID()]; ok {
 		for _, v := range before {
-			graph.AddEdge(uidOrPanic(v), p.UID())
+			addEdge(uidOrPanic(v), p.UID())
 		}
 	}
 	return
@@ -417,8 +536,21 @@ func (pls *Plugins) sortForInit() (sorted []*Plugin, err error) {
 		Plugins: pls.plugins,
 		Afters:  pls.afters,
 		Befores: pls.befores,
+		Post: func(state *SorterState) error {
+			pls.deps = state.Deps
+			return nil
+		},
 	}
-	sorted, err = sorter.Sort(pls.sortf)
+	providers := pls.serviceProviders()
+	sortfs := []func(state *SorterState, p *Plugin) error{pls.sortf, pls.sortServices(providers), pls.sortInject(providers)}
+	sorted, err = sorter.Sort(func(state *SorterState, p *Plugin) (err error) {
+		for _, f := range sortfs {
+			if err = f(state, p); err != nil {
+				return
+			}
+		}
+		return
+	})
 	return
 }
 
@@ -426,6 +558,7 @@ func (pls *Plugins) initPlugin(p *Plugin) (err error) {
 	log := logging.WithPrefix(logging.WithPrefix(pls.log, "init plugin"), p.String())
 	log.Debug("start")
 	defer log.Debug("done")
+	defer pls.withCurrent(p)()
 	options := pls.Options()
 
 	if requireOptions, ok := p.Value.(PluginRequireOptions); ok {
@@ -451,28 +584,41 @@ func (pls *Plugins) initPlugin(p *Plugin) (err error) {
 		return err
 	}
 
-	switch pl := p.Value.(type) {
-	case PluginInit:
-		pl.Init()
-	case PluginInitE:
-		err = pl.Init()
-		if err != nil {
-			return errwrap.Wrap(err, "Init")
-		}
-	case PluginInitOptions:
-		pl.Init(options)
-	case PluginInitOptionsE:
-		err = pl.Init(options)
-		if err != nil {
-			return errwrap.Wrap(err, "Init")
-		}
+	if err = pls.extensionsBeforePluginInit(p); err != nil {
+		return
+	}
+
+	callInit := func() error {
+		return pls.call(CallInfo{PhaseInit, p}, func() (err error) {
+			switch pl := p.Value.(type) {
+			case PluginInit:
+				pl.Init()
+			case PluginInitE:
+				err = pl.Init()
+			case PluginInitOptions:
+				pl.Init(options)
+			case PluginInitOptionsE:
+				err = pl.Init(options)
+			}
+			return
+		})
+	}
+	if r, ok := p.Value.(PluginInitRetry); ok {
+		err = r.InitRetry().Do(pls.Context(), log, callInit)
+	} else {
+		err = callInit()
+	}
+	if err != nil {
+		return errwrap.Wrap(err, "Init")
 	}
 
 	if err = pls.TriggerPlugins(edis.NewEvent("init"), p); err != nil {
 		return errwrap.Wrap(err, "Init")
 	}
-	err = pls.TriggerPlugins(edis.NewEvent(E_INIT_DONE), p)
-	return
+	if err = pls.TriggerPlugins(edis.NewEvent(E_INIT_DONE), p); err != nil {
+		return
+	}
+	return pls.extensionsAfterPluginInit(p)
 }
 
 func (pls *Plugins) Init() (err error) {
@@ -488,9 +634,11 @@ func (pls *Plugins) Init() (err error) {
 	}
 
 	pls.plugins = sorted
+	pls.resetImplementing()
 
 	log.Debug("init extensions")
 
+	pls.sortExtensions()
 	for _, extension := range pls.Extensions {
 		err = extension.Init(pls)
 		if err != nil {
@@ -508,10 +656,22 @@ func (pls *Plugins) Init() (err error) {
 	log.Debug("init plugins")
 
 	for _, p := range sorted {
-		if IsInitializador(p) {
-			if err = pls.initPlugin(p); err != nil {
+		if pls.continueOnError {
+			if dep := pls.failedDependency(p); dep != "" {
+				pls.markFailed(p, DependencyFailedError{p.UID(), dep})
+				continue
+			}
+		}
+		// plugins without Init method may also have injected fields
+		if err = pls.Inject(p); err == nil && IsInitializador(p) {
+			err = pls.initPlugin(p)
+		}
+		if err != nil {
+			if !pls.continueOnError {
 				return
 			}
+			pls.markFailed(p, err)
+			err = nil
 		}
 	}
 
@@ -521,10 +681,28 @@ func (pls *Plugins) Init() (err error) {
 		return
 	}
 
-	err = pls.Trigger(edis.NewEvent(E_INIT_DONE))
+	if err = pls.validateContributions(); err != nil {
+		return
+	}
+
+	err = pls.TriggerSticky(edis.NewEvent(E_INIT_DONE))
 	if err != nil {
 		return errwrap.Wrap(err, "Plugins > Init > Trigger:initDone")
 	}
-	pls.TriggerPlugins(edis.NewEvent(E_POST_INIT))
+	pls.initDone.Store(true)
+	if err = pls.extensionsInitDone(); err != nil {
+		return errwrap.Wrap(err, "Plugins > Init > Extensions:initDone")
+	}
+	if active := pls.activePlugins(); len(active) > 0 {
+		pls.TriggerPluginsSticky(edis.NewEvent(E_POST_INIT), active...)
+	}
+	pls.postInitDone = true
+	if !pls.bootReport.OK() {
+		pls.log.Warning(pls.bootReport.String())
+	}
+	if pls.freezeOptions {
+		pls.options.Mutable(pls.mutableOptions...)
+		pls.options.Freeze()
+	}
 	return errwrap.Wrap(err, "Plugins > Init > Trigger:postInit")
 }
\ No newline at end of file
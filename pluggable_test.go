@@ -0,0 +1,84 @@
+package pluggable
+
+import (
+	"errors"
+	"testing"
+)
+
+type addPluginA struct{}
+type addPluginB struct{}
+type addPluginVetoed struct{}
+type addPluginPanic struct{}
+
+func (addPluginPanic) OnRegister() {
+	panic("boom")
+}
+
+func TestAddRemovesCancelledAndFailedPlugins(t *testing.T) {
+	pls := NewPlugins()
+	pls.OnPlugin(E_REGISTER, func(e PluginEventInterface) {
+		if _, ok := e.Plugin().Value.(*addPluginVetoed); ok {
+			e.Cancel("vetoed")
+		}
+	})
+
+	err := pls.Add(&addPluginA{}, &addPluginVetoed{}, &addPluginPanic{}, &addPluginB{})
+	var multi *MultiError
+	if !errors.As(err, &multi) || len(multi.Errors) != 2 {
+		t.Fatalf("expected *MultiError with 2 errors, got %v", err)
+	}
+	var cancelled *PluginCancelledError
+	if !errors.As(err, &cancelled) || cancelled.UID != UID(&addPluginVetoed{}) {
+		t.Errorf("expected the cancelled error of vetoed plugin, got %v", err)
+	}
+	var panicErr *PluginPanicError
+	if !errors.As(err, &panicErr) {
+		t.Errorf("expected the panic error of register, got %v", err)
+	}
+
+	for _, v := range []interface{}{&addPluginVetoed{}, &addPluginPanic{}} {
+		if pls.ByUID.Has(UID(v)) {
+			t.Errorf("%s is registered", UID(v))
+		}
+	}
+	var uids []string
+	for _, p := range pls.GetPlugins() {
+		uids = append(uids, p.UID())
+	}
+	if want := UIDs(&addPluginA{}, &addPluginB{}); len(uids) != 2 || uids[0] != want[0] || uids[1] != want[1] {
+		t.Errorf("plugins = %v, want %v", uids, want)
+	}
+
+	// the removed plugins can be added again
+	if err = pls.Add(&addPluginPanic{}); err == nil {
+		t.Error("expected the panic error again")
+	}
+}
+
+type addPluginInitFail struct{}
+
+func (addPluginInitFail) Init() error {
+	return errors.New("fail")
+}
+
+func TestAddAfterInit(t *testing.T) {
+	pls := NewPlugins()
+	if err := pls.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := pls.Add(&addPluginInitFail{}); err == nil {
+		t.Fatal("expected init error")
+	}
+	uid := UID(&addPluginInitFail{})
+	if pls.ByUID.Has(uid) {
+		t.Errorf("%s is registered", uid)
+	}
+
+	pls.SetContinueOnError(true)
+	if err := pls.Add(&addPluginInitFail{}); err != nil {
+		t.Fatal(err)
+	}
+	if !pls.ByUID.Has(uid) || pls.Failed(uid) == nil {
+		t.Errorf("%s is not registered as failed", uid)
+	}
+}
@@ -0,0 +1,83 @@
+package pluggable
+
+type QueryMode int
+
+const (
+	// QueryAll collects the results of all plugins.
+	QueryAll QueryMode = iota
+	// QueryFirst stops on the first result without error.
+	QueryFirst
+)
+
+type QueryResult struct {
+	PluginUID string
+	Value     interface{}
+	Err       error
+}
+
+// QueryEvent is the event of Query. The handlers answers it using Respond.
+type QueryEvent struct {
+	PluginEventInterface
+	Query   interface{}
+	Mode    QueryMode
+	results []QueryResult
+}
+
+// Respond adds the result of current plugin. In QueryFirst mode, the success response stops the propagation.
+func (e *QueryEvent) Respond(value interface{}, err error) {
+	var uid string
+	if p := e.Plugin(); p != nil {
+		uid = p.UID()
+	}
+	e.results = append(e.results, QueryResult{uid, value, err})
+	if e.Mode == QueryFirst && err == nil {
+		e.StopPropagation()
+	}
+}
+
+func (e *QueryEvent) Results() []QueryResult {
+	return e.results
+}
+
+// QueryResponder is the plugin value that answers queries. If ok is false, the query is ignored.
+type QueryResponder interface {
+	RespondQuery(name string, query interface{}) (value interface{}, ok bool, err error)
+}
+
+// OnQuery registers the callback that answers the name query on dispatcher (like the plugin dispatcher).
+func OnQuery(dis EventDispatcherInterface, name string, cb func(query interface{}) (value interface{}, err error)) {
+	dis.On(name, func(e PluginEventInterface) {
+		if qe, ok := EventAs[*QueryEvent](e); ok && !qe.PropagationStopped() {
+			qe.Respond(cb(qe.Query))
+		}
+	})
+}
+
+// Query asks the plugins (all, if empty) for the name query and returns the results in plugins order.
+// The plugins answers by QueryResponder, OnQuery into their dispatcher, or OnPlugin handlers calling
+// Respond of the QueryEvent (see EventAs). Handlers errors are returned as result error.
+func (ped *PluginEventDispatcher) Query(name string, query interface{}, mode QueryMode, plugins ...*Plugin) (results []QueryResult) {
+	if len(plugins) == 0 {
+		plugins = ped.GetPlugins()
+	}
+	dis := ped.PluginDispatcher()
+	for _, p := range plugins {
+		e := &QueryEvent{PluginEventInterface: NewPluginEvent(name), Query: query, Mode: mode}
+		e.SetPlugin(p)
+		if r, ok := p.Value.(QueryResponder); ok {
+			if value, ok, err := r.RespondQuery(name, query); ok {
+				e.Respond(value, err)
+			}
+		}
+		if !e.PropagationStopped() {
+			if err := dis.TriggerPlugins(e, p); err != nil {
+				e.results = append(e.results, QueryResult{p.UID(), nil, err})
+			}
+		}
+		results = append(results, e.results...)
+		if mode == QueryFirst && e.PropagationStopped() {
+			return
+		}
+	}
+	return
+}
@@ -0,0 +1,108 @@
+package pluggable
+
+import (
+	"sort"
+
+	errwrap "github.com/moisespsena-go/error-wrap"
+)
+
+type OptionsChangedEvent struct {
+	PluginEventInterface
+	Changed []string
+	// Rollback is true if the changes was reverted because a plugin rejects it.
+	Rollback bool
+}
+
+func NewOptionsChangedEvent(changed []string, rollback bool) *OptionsChangedEvent {
+	return &OptionsChangedEvent{NewPluginEvent(E_OPTIONS_CHANGED), changed, rollback}
+}
+
+func OnOptionsChanged(p EventDispatcherInterface, cb func(e *OptionsChangedEvent) error) {
+	p.On(E_OPTIONS_CHANGED, func(e PluginEventInterface) error {
+		oe, err := optionsChangedEvent(e)
+		if err != nil {
+			return err
+		}
+		return cb(oe)
+	})
+}
+
+func optionsChangedEvent(e EventInterface) (oe *OptionsChangedEvent, err error) {
+	var ok bool
+	if oe, ok = EventAs[*OptionsChangedEvent](e); !ok {
+		err = eventTypeMismatch(E_OPTIONS_CHANGED, e, oe)
+	}
+	return
+}
+
+func reconfigurePlugin(e PluginEventInterface) error {
+	if r, ok := e.Plugin().Value.(PluginReconfigure); ok {
+		oe, err := optionsChangedEvent(e)
+		if err != nil {
+			return err
+		}
+		return r.Reconfigure(oe.Changed)
+	}
+	return nil
+}
+
+// Reconfigure sets the values into global options and notify the plugins. If any plugin rejects the
+// change, the old values are restored and the plugins already notified receives the rollback event.
+func (pls *Plugins) Reconfigure(values map[string]interface{}) (err error) {
+	var (
+		options = pls.Options()
+		changed = make([]string, 0, len(values))
+		olds    = map[string]interface{}{}
+		sources = map[string]string{}
+		applied []string
+		done    []*Plugin
+	)
+
+	for key := range values {
+		changed = append(changed, key)
+	}
+	sort.Strings(changed)
+
+	rollback := func() {
+		for _, key := range applied {
+			if old, ok := olds[key]; ok {
+				options.SetFrom(sources[key], key, old)
+			} else {
+				options.Del(key)
+			}
+		}
+	}
+
+	for _, key := range changed {
+		var (
+			old interface{}
+			ok  bool
+		)
+		if old, ok, err = options.GetE(key); err != nil {
+			rollback()
+			return errwrap.Wrap(err, "Reconfigure")
+		}
+		if ok {
+			olds[key], sources[key] = old, options.Source(key)
+		}
+		if err = options.Set(key, values[key]); err != nil {
+			rollback()
+			return errwrap.Wrap(err, "Reconfigure")
+		}
+		applied = append(applied, key)
+	}
+
+	for _, p := range pls.activePlugins() {
+		if err = pls.TriggerPlugins(NewOptionsChangedEvent(changed, false), p); err != nil {
+			rollback()
+			if len(done) > 0 {
+				if err2 := pls.TriggerPlugins(NewOptionsChangedEvent(changed, true), done...); err2 != nil {
+					pls.log.Errorf("Reconfigure rollback failed: %v", err2)
+				}
+			}
+			return errwrap.Wrap(err, "Reconfigure")
+		}
+		done = append(done, p)
+	}
+	return
+}
@@ -0,0 +1,165 @@
+package pluggable
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+)
+
+// TraceSpan is the record of event trigger or handler call.
+type TraceSpan struct {
+	Name    string    `json:"name"`
+	Plugin  string    `json:"plugin,omitempty"`
+	Handler string    `json:"handler,omitempty"`
+	Start   time.Time `json:"start"`
+	End     time.Time `json:"end"`
+	Error   string    `json:"error,omitempty"`
+	// Depth is the nesting depth into the Goroutine (the async queues workers have their own)
+	Depth     int    `json:"depth"`
+	Goroutine uint64 `json:"goroutine"`
+}
+
+func (s TraceSpan) Duration() time.Duration {
+	return s.End.Sub(s.Start)
+}
+
+// EventRecorder records the events triggers and handlers calls timeline. Set it on dispatcher using
+// SetRecorder.
+type EventRecorder struct {
+	mu     sync.Mutex
+	spans  []TraceSpan
+	depths map[uint64]int
+}
+
+func NewEventRecorder() *EventRecorder {
+	return &EventRecorder{}
+}
+
+// Begin starts the span. The returned func ends it. Nil recorder is a no-op.
+func (r *EventRecorder) Begin(name, plugin, handler string) (end func(err error)) {
+	if r == nil {
+		return func(error) {}
+	}
+	gid := goroutineID()
+	r.mu.Lock()
+	if r.depths == nil {
+		r.depths = map[uint64]int{}
+	}
+	depth := r.depths[gid]
+	r.depths[gid]++
+	r.mu.Unlock()
+	start := time.Now()
+	return func(err error) {
+		span := TraceSpan{Name: name, Plugin: plugin, Handler: handler, Start: start, End: time.Now(),
+			Depth: depth, Goroutine: gid}
+		if err != nil {
+			span.Error = err.Error()
+		}
+		r.mu.Lock()
+		if r.depths[gid] > 1 {
+			r.depths[gid]--
+		} else {
+			delete(r.depths, gid)
+		}
+		r.spans = append(r.spans, span)
+		r.mu.Unlock()
+	}
+}
+
+// Spans returns the recorded spans sorted by start time.
+func (r *EventRecorder) Spans() []TraceSpan {
+	r.mu.Lock()
+	spans := append([]TraceSpan{}, r.spans...)
+	r.mu.Unlock()
+	sort.SliceStable(spans, func(i, j int) bool {
+		return spans[i].Start.Before(spans[j].Start)
+	})
+	return spans
+}
+
+func (r *EventRecorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spans = nil
+}
+
+func (r *EventRecorder) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r.Spans())
+}
+
+type chromeTraceEvent struct {
+	Name string                 `json:"name"`
+	Cat  string                 `json:"cat"`
+	Ph   string                 `json:"ph"`
+	Ts   int64                  `json:"ts"`
+	Dur  int64                  `json:"dur"`
+	Pid  int                    `json:"pid"`
+	Tid  int                    `json:"tid"`
+	Args map[string]interface{} `json:"args,omitempty"`
+}
+
+// WriteChromeTrace writes the spans in Chrome trace event format, loadable by chrome://tracing or Perfetto.
+// Each goroutine (like each async queue worker) is a trace thread.
+func (r *EventRecorder) WriteChromeTrace(w io.Writer) error {
+	spans := r.Spans()
+	events := make([]chromeTraceEvent, len(spans))
+	tids := map[uint64]int{}
+	var origin time.Time
+	if len(spans) > 0 {
+		origin = spans[0].Start
+	}
+	for i, s := range spans {
+		tid, ok := tids[s.Goroutine]
+		if !ok {
+			tid = len(tids) + 1
+			tids[s.Goroutine] = tid
+		}
+		args := map[string]interface{}{"depth": s.Depth}
+		if s.Plugin != "" {
+			args["plugin"] = s.Plugin
+		}
+		if s.Handler != "" {
+			args["handler"] = s.Handler
+		}
+		if s.Error != "" {
+			args["error"] = s.Error
+		}
+		events[i] = chromeTraceEvent{
+			Name: s.Name,
+			Cat:  "event",
+			Ph:   "X",
+			Ts:   s.Start.Sub(origin).Microseconds(),
+			Dur:  s.Duration().Microseconds(),
+			Pid:  1,
+			Tid:  tid,
+			Args: args,
+		}
+	}
+	return json.NewEncoder(w).Encode(map[string]interface{}{"traceEvents": events})
+}
+
+// SetRecorder sets the events recorder. Nil disables recording.
+func (ed *EventDispatcher) SetRecorder(r *EventRecorder) {
+	ed.recorder = r
+}
+
+func (ed *EventDispatcher) Recorder() *EventRecorder {
+	return ed.recorder
+}
+
+// callbackName returns the identity of callback: the function name or their type.
+func callbackName(cb interface{}) string {
+	if v := reflect.ValueOf(cb); v.Kind() == reflect.Func {
+		if f := runtime.FuncForPC(v.Pointer()); f != nil {
+			return f.Name()
+		}
+	}
+	return fmt.Sprintf("%T", cb)
+}
@@ -0,0 +1,92 @@
+package pluggable
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/moisespsena-go/logging"
+)
+
+// RetryPolicy defines how the plugin Init is retried on failure.
+type RetryPolicy struct {
+	// Attempts is the max number of calls. Values less than 2 disables retry.
+	Attempts int
+	// Backoff is the delay before the second attempt.
+	Backoff time.Duration
+	// Multiplier multiplies the delay for each attempt. The default is 2.
+	Multiplier float64
+	// MaxBackoff is the max delay. Zero is unlimited.
+	MaxBackoff time.Duration
+	// Jitter is the random fraction (0 to 1) added or removed from delay.
+	Jitter float64
+	// Retryable reports if err can be retried. If nil, all errors are retryable.
+	Retryable func(err error) bool
+}
+
+type PluginInitRetry interface {
+	InitRetry() RetryPolicy
+}
+
+func (r RetryPolicy) delay(attempt int) time.Duration {
+	multiplier := r.Multiplier
+	if multiplier <= 0 {
+		multiplier = 2
+	}
+	d := float64(r.Backoff) * math.Pow(multiplier, float64(attempt-1))
+	if r.MaxBackoff > 0 && d > float64(r.MaxBackoff) {
+		d = float64(r.MaxBackoff)
+	}
+	if r.Jitter > 0 {
+		d += d * r.Jitter * (2*rand.Float64() - 1)
+	}
+	return time.Duration(d)
+}
+
+func (r RetryPolicy) retryable(err error) bool {
+	var panicErr *PluginPanicError
+	if errors.As(err, &panicErr) {
+		return false
+	}
+	return r.Retryable == nil || r.Retryable(err)
+}
+
+// Do calls f while it fails, until attempts, non retryable error or ctx done. If ctx is done, the
+// returned error wraps ctx.Err() and the last f error, if any.
+func (r RetryPolicy) Do(ctx context.Context, log logging.Logger, f func() error) (err error) {
+	if err = ctx.Err(); err != nil {
+		return fmt.Errorf("Retry canceled before first attempt: %w", err)
+	}
+	for attempt := 1; ; attempt++ {
+		if err = f(); err == nil || attempt >= r.Attempts || !r.retryable(err) {
+			return
+		}
+		d := r.delay(attempt)
+		if log != nil {
+			log.Warningf("attempt %d/%d failed, retrying in %s: %v", attempt, r.Attempts, d, err)
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("Retry canceled after %d attempts: %w: %w", attempt, ctx.Err(), err)
+		case <-time.After(d):
+		}
+	}
+}
+
+// InitContext initializes the plugins with the boot context. The context is used by retry policies
+// of plugins Init.
+func (pls *Plugins) InitContext(ctx context.Context) error {
+	pls.ctx = ctx
+	return pls.Init()
+}
+
+// Context returns the boot context.
+func (pls *Plugins) Context() context.Context {
+	if pls.ctx == nil {
+		return context.Background()
+	}
+	return pls.ctx
+}
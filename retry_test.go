@@ -0,0 +1,103 @@
+package pluggable
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyDelay(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy RetryPolicy
+		want   []time.Duration
+	}{
+		{"default multiplier", RetryPolicy{Backoff: time.Second},
+			[]time.Duration{time.Second, 2 * time.Second, 4 * time.Second}},
+		{"multiplier", RetryPolicy{Backoff: time.Second, Multiplier: 3},
+			[]time.Duration{time.Second, 3 * time.Second, 9 * time.Second}},
+		{"max backoff", RetryPolicy{Backoff: time.Second, MaxBackoff: 3 * time.Second},
+			[]time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i, want := range tt.want {
+				if got := tt.policy.delay(i + 1); got != want {
+					t.Errorf("delay(%d) = %s, want %s", i+1, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryPolicyDelayJitter(t *testing.T) {
+	policy := RetryPolicy{Backoff: time.Second, Jitter: 0.5}
+	for i := 0; i < 100; i++ {
+		if d := policy.delay(1); d < 500*time.Millisecond || d > 1500*time.Millisecond {
+			t.Fatalf("delay %s out of jitter range", d)
+		}
+	}
+}
+
+func TestRetryPolicyDo(t *testing.T) {
+	fail := errors.New("fail")
+	policy := RetryPolicy{Attempts: 3, Backoff: time.Millisecond}
+
+	var calls int
+	err := policy.Do(context.Background(), nil, func() error {
+		calls++
+		return fail
+	})
+	if err != fail || calls != 3 {
+		t.Errorf("Do = %v after %d calls, want %v after 3", err, calls, fail)
+	}
+
+	calls = 0
+	err = policy.Do(context.Background(), nil, func() error {
+		if calls++; calls < 2 {
+			return fail
+		}
+		return nil
+	})
+	if err != nil || calls != 2 {
+		t.Errorf("Do = %v after %d calls, want success after 2", err, calls)
+	}
+}
+
+func TestRetryPolicyDoesNotRetryPanics(t *testing.T) {
+	policy := RetryPolicy{Attempts: 3, Backoff: time.Millisecond}
+	var calls int
+	policy.Do(context.Background(), nil, func() error {
+		calls++
+		return fmt.Errorf("intercepted: %w", &PluginPanicError{UID: "p", Phase: PhaseInit, Value: "boom"})
+	})
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+}
+
+func TestRetryPolicyDoCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	policy := RetryPolicy{Attempts: 3, Backoff: time.Hour}
+	var calls int
+	err := policy.Do(ctx, nil, func() error {
+		calls++
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) || calls != 0 {
+		t.Errorf("Do = %v after %d calls, want context.Canceled after 0", err, calls)
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	fail := errors.New("fail")
+	err = policy.Do(ctx, nil, func() error {
+		cancel()
+		return fail
+	})
+	if !errors.Is(err, context.Canceled) || !errors.Is(err, fail) {
+		t.Errorf("Do = %v, want context.Canceled and %v", err, fail)
+	}
+}
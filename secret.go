@@ -0,0 +1,264 @@
+package pluggable
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	errwrap "github.com/moisespsena-go/error-wrap"
+)
+
+const Redacted = "******"
+
+// Secret is a sensitive option value. Their string, fmt and JSON representations are redacted. Use
+// Value to get the real value.
+type Secret struct {
+	value string
+}
+
+func NewSecret(value string) Secret {
+	return Secret{value}
+}
+
+func (s Secret) Value() string {
+	return s.value
+}
+
+func (s Secret) String() string {
+	return Redacted
+}
+
+func (s Secret) GoString() string {
+	return Redacted
+}
+
+func (s Secret) Format(f fmt.State, verb rune) {
+	io.WriteString(f, Redacted)
+}
+
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(Redacted)
+}
+
+func (s Secret) MarshalText() ([]byte, error) {
+	return []byte(Redacted), nil
+}
+
+// SecretSource resolves the secret value by the source specific reference.
+type SecretSource interface {
+	ResolveSecret(ref string) (value string, err error)
+}
+
+type SecretSourceFunc func(ref string) (value string, err error)
+
+func (f SecretSourceFunc) ResolveSecret(ref string) (value string, err error) {
+	return f(ref)
+}
+
+// EnvSecretSource resolves secrets from environment variables.
+type EnvSecretSource struct {
+	Prefix string
+}
+
+func (s EnvSecretSource) ResolveSecret(ref string) (value string, err error) {
+	var ok bool
+	if value, ok = os.LookupEnv(s.Prefix + ref); !ok {
+		return "", fmt.Errorf("environment variable %q does not exists", s.Prefix+ref)
+	}
+	return
+}
+
+// FileSecretSource resolves secrets from file contents. The trailing new lines are removed.
+type FileSecretSource struct {
+	Dir string
+}
+
+func (s FileSecretSource) ResolveSecret(ref string) (value string, err error) {
+	pth := ref
+	if s.Dir != "" && !filepath.IsAbs(pth) {
+		pth = filepath.Join(s.Dir, pth)
+	}
+	var data []byte
+	if data, err = ioutil.ReadFile(pth); err != nil {
+		return
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// EncryptedFileSecretSource resolves secrets from local file encrypted by EncryptSecrets.
+type EncryptedFileSecretSource struct {
+	Path    string
+	Key     []byte
+	once    sync.Once
+	secrets map[string]string
+	err     error
+}
+
+func (s *EncryptedFileSecretSource) load() {
+	var data []byte
+	if data, s.err = ioutil.ReadFile(s.Path); s.err != nil {
+		return
+	}
+	if s.secrets, s.err = DecryptSecrets(s.Key, data); s.err != nil {
+		s.err = errwrap.Wrap(s.err, "Secrets file %q", s.Path)
+	}
+}
+
+func (s *EncryptedFileSecretSource) ResolveSecret(ref string) (value string, err error) {
+	s.once.Do(s.load)
+	if s.err != nil {
+		return "", s.err
+	}
+	var ok bool
+	if value, ok = s.secrets[ref]; !ok {
+		return "", fmt.Errorf("secret %q does not exists in %q", ref, s.Path)
+	}
+	return
+}
+
+// EncryptSecrets encrypts secrets using AES-GCM. The key size must be 16, 24 or 32 bytes.
+func EncryptSecrets(key []byte, secrets map[string]string) (data []byte, err error) {
+	var (
+		gcm       cipher.AEAD
+		plaintext []byte
+	)
+	if gcm, err = newGCM(key); err != nil {
+		return
+	}
+	if plaintext, err = json.Marshal(secrets); err != nil {
+		return
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return
+	}
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func DecryptSecrets(key []byte, data []byte) (secrets map[string]string, err error) {
+	var (
+		gcm       cipher.AEAD
+		plaintext []byte
+	)
+	if gcm, err = newGCM(key); err != nil {
+		return
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, fmt.Errorf("invalid encrypted secrets data")
+	}
+	nonce, data := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	if plaintext, err = gcm.Open(nil, nonce, data, nil); err != nil {
+		return
+	}
+	err = json.Unmarshal(plaintext, &secrets)
+	return
+}
+
+func newGCM(key []byte) (gcm cipher.AEAD, err error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return
+	}
+	return cipher.NewGCM(block)
+}
+
+// SecretRef references a secret from SecretSource registered as Source.
+type SecretRef struct {
+	Source, Ref string
+}
+
+func (r SecretRef) String() string {
+	return r.Source + ":" + r.Ref
+}
+
+// SetSecret sets the key value as Secret.
+func (o *Options) SetSecret(key, value string) error {
+	return o.Set(key, NewSecret(value))
+}
+
+// SetSecretRef sets the key value as reference to secret resolved by Plugins.ProvideOptions. After
+// it, the reference is resolved immediately.
+func (o *Options) SetSecretRef(key, source, ref string) (err error) {
+	o.mu.RLock()
+	resolve := o.resolveSecret
+	o.mu.RUnlock()
+	if resolve != nil {
+		return resolve(key, SecretRef{source, ref})
+	}
+	if err = o.Set(key, SecretRef{source, ref}); err != nil {
+		return
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.secretRefs = append(o.secretRefs, key)
+	return
+}
+
+// secretResolved removes key from the references to be resolved.
+func (o *Options) secretResolved(key string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	refs := o.secretRefs[:0]
+	for _, k := range o.secretRefs {
+		if k != key {
+			refs = append(refs, k)
+		}
+	}
+	o.secretRefs = refs
+}
+
+// SecretSource registers the secret source as name.
+func (pls *Plugins) SecretSource(name string, source SecretSource) {
+	if pls.secretSources == nil {
+		pls.secretSources = map[string]SecretSource{}
+	}
+	pls.secretSources[name] = source
+}
+
+// resolveSecrets resolves the secrets references. Each reference is removed only after their
+// resolution, so a failed one is resolved again by the next call.
+func (pls *Plugins) resolveSecrets() (err error) {
+	options := pls.Options()
+	options.mu.RLock()
+	keys := append([]string{}, options.secretRefs...)
+	options.mu.RUnlock()
+
+	for _, key := range keys {
+		options.mu.RLock()
+		v, _ := options.peek(key)
+		options.mu.RUnlock()
+		if ref, ok := v.(SecretRef); ok {
+			if err = pls.resolveSecret(options, key, ref); err != nil {
+				return
+			}
+		}
+		options.secretResolved(key)
+	}
+
+	options.mu.Lock()
+	defer options.mu.Unlock()
+	options.resolveSecret = func(key string, ref SecretRef) error {
+		return pls.resolveSecret(options, key, ref)
+	}
+	return
+}
+
+func (pls *Plugins) resolveSecret(options *Options, key string, ref SecretRef) (err error) {
+	source, ok := pls.secretSources[ref.Source]
+	if !ok {
+		return fmt.Errorf("Secret option %q: source %q not registered", key, ref.Source)
+	}
+	var value string
+	if value, err = source.ResolveSecret(ref.Ref); err != nil {
+		return errwrap.Wrap(err, "Secret option %q from %s", key, ref)
+	}
+	return options.SetFrom(ref.Source, key, NewSecret(value))
+}
@@ -0,0 +1,154 @@
+package pluggable
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+type serviceEntry struct {
+	plugin *Plugin
+	value  interface{}
+}
+
+type ServiceNotFoundError struct {
+	Type reflect.Type
+}
+
+func (e ServiceNotFoundError) Error() string {
+	return fmt.Sprintf("Service %s does not have provider", e.Type)
+}
+
+type ServiceAmbiguousError struct {
+	Type      reflect.Type
+	Providers []string
+}
+
+func (e ServiceAmbiguousError) Error() string {
+	return fmt.Sprintf("Service %s has multiple providers: %s", e.Type, strings.Join(e.Providers, ", "))
+}
+
+// ServiceType returns the service key of T. Use it into PluginProvideServices and PluginRequireServices.
+func ServiceType[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+// Provide registers impl as T service provided by the plugin in their own registration or
+// initialization. Out of it, like into event handlers, use ProvideBy.
+func Provide[T any](pls *Plugins, impl T) error {
+	return ProvideBy[T](pls, pls.current.Load(), impl)
+}
+
+// ProvideBy registers impl as T service provided by plugin.
+func ProvideBy[T any](pls *Plugins, plugin *Plugin, impl T) error {
+	typ := ServiceType[T]()
+	if plugin == nil {
+		return fmt.Errorf("Service %s: provider plugin is nil. Out of plugin registration or initialization, use ProvideBy", typ)
+	}
+	pls.servicesMu.Lock()
+	defer pls.servicesMu.Unlock()
+	if pls.services == nil {
+		pls.services = map[reflect.Type][]serviceEntry{}
+	}
+	pls.services[typ] = append(pls.services[typ], serviceEntry{plugin, impl})
+	return nil
+}
+
+// Resolve returns the T service. Returns error if it does not have or has multiple providers.
+func Resolve[T any](pls *Plugins) (impl T, err error) {
+	var value interface{}
+	if value, err = pls.ResolveService(ServiceType[T]()); err != nil {
+		return
+	}
+	// value is nil if the provider registered a nil interface
+	impl, _ = value.(T)
+	return
+}
+
+// ResolveAll returns all T services in provider order.
+func ResolveAll[T any](pls *Plugins) (impls []T) {
+	for _, value := range pls.ResolveAllServices(ServiceType[T]()) {
+		impl, _ := value.(T)
+		impls = append(impls, impl)
+	}
+	return
+}
+
+func (pls *Plugins) ResolveService(typ reflect.Type) (value interface{}, err error) {
+	pls.servicesMu.RLock()
+	defer pls.servicesMu.RUnlock()
+	entries := pls.services[typ]
+	switch len(entries) {
+	case 0:
+		return nil, ServiceNotFoundError{typ}
+	case 1:
+		return entries[0].value, nil
+	default:
+		providers := make([]string, len(entries))
+		for i, entry := range entries {
+			providers[i] = fmt.Sprint(entry.plugin)
+		}
+		return nil, ServiceAmbiguousError{typ, providers}
+	}
+}
+
+func (pls *Plugins) ResolveAllServices(typ reflect.Type) (values []interface{}) {
+	pls.servicesMu.RLock()
+	defer pls.servicesMu.RUnlock()
+	for _, entry := range pls.services[typ] {
+		values = append(values, entry.value)
+	}
+	return
+}
+
+// serviceProviders returns the plugins UIDs by declared provided service.
+func (pls *Plugins) serviceProviders() (providers map[reflect.Type][]string) {
+	providers = map[reflect.Type][]string{}
+	for _, p := range pls.plugins {
+		if provides, ok := p.Value.(PluginProvideServices); ok {
+			for _, typ := range provides.ProvideServices() {
+				providers[typ] = append(providers[typ], p.UID())
+			}
+		}
+	}
+	return
+}
+
+func (pls *Plugins) sortServices(providers map[reflect.Type][]string) func(state *SorterState, p *Plugin) (err error) {
+	return func(state *SorterState, p *Plugin) (err error) {
+		if requires, ok := p.Value.(PluginRequireServices); ok {
+			uid := p.UID()
+			for _, typ := range requires.RequireServices() {
+				uids, ok := providers[typ]
+				if !ok {
+					return fmt.Errorf("Service %s, required by %s, does not have provider", typ, uid)
+				}
+				for _, providerUID := range uids {
+					if providerUID != uid {
+						state.AddEdge(uid, providerUID)
+					}
+				}
+			}
+		}
+		return
+	}
+}
+
+// removeServices removes the services provided by plugin.
+func (pls *Plugins) removeServices(plugin *Plugin) {
+	pls.servicesMu.Lock()
+	defer pls.servicesMu.Unlock()
+	for typ, entries := range pls.services {
+		var keep []serviceEntry
+		for _, entry := range entries {
+			if entry.plugin != plugin {
+				keep = append(keep, entry)
+			}
+		}
+		if len(keep) == 0 {
+			delete(pls.services, typ)
+		} else {
+			pls.services[typ] = keep
+		}
+	}
+}
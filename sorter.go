@@ -12,6 +12,14 @@ type SorterState struct {
 	Graph          *topsort.Graph
 	pluginsMap     PluginsMap
 	Befors, Afters map[string][]string
+	// Deps are the dependencies UIDs by plugin UID, added by AddEdge
+	Deps map[string][]string
+}
+
+// AddEdge adds the dependency of from plugin UID to the to plugin UID.
+func (this *SorterState) AddEdge(from, to string) {
+	this.Graph.AddEdge(from, to)
+	this.Deps[from] = append(this.Deps[from], to)
 }
 
 func (this SorterState) UidOrPanic(v interface{}) string {
@@ -46,6 +54,7 @@ func (this Sorter) Sort(do func(state *SorterState, p *Plugin) (err error)) (res
 			pluginsMap: this.PluginsMap,
 			Afters:     this.Afters,
 			Befors:     this.Befores,
+			Deps:       map[string][]string{},
 		}
 	)
 	log.Debug("sort")
@@ -81,6 +90,12 @@ func (this Sorter) Sort(do func(state *SorterState, p *Plugin) (err error)) (res
 	for i, uid := range resultNames {
 		result[i] = state.pluginsMap[uid]
 	}
+
+	if this.Post != nil {
+		if err = this.Post(state); err != nil {
+			return nil, err
+		}
+	}
 	log.Debug("sort done")
 	return
 }
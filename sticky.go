@@ -0,0 +1,99 @@
+package pluggable
+
+type stickyPluginsEvent struct {
+	e       EventInterface
+	plugins []*Plugin
+}
+
+// TriggerSticky triggers the event and remembers it: callbacks registered later by On or OnE with
+// this event name (or matching pattern) receives it on registration.
+func (ed *EventDispatcher) TriggerSticky(e EventInterface) error {
+	ed.patternsMu.Lock()
+	if ed.sticky == nil {
+		ed.sticky = map[string]EventInterface{}
+	}
+	ed.sticky[e.Name()] = e
+	ed.patternsMu.Unlock()
+	return ed.Trigger(e)
+}
+
+// Sticky returns the last sticky event named name.
+func (ed *EventDispatcher) Sticky(name string) EventInterface {
+	ed.patternsMu.RLock()
+	defer ed.patternsMu.RUnlock()
+	return ed.sticky[name]
+}
+
+func (ed *EventDispatcher) replaySticky(eventName string, callbacks []interface{}) (err error) {
+	ed.patternsMu.RLock()
+	var events []EventInterface
+	for name, e := range ed.sticky {
+		if name == eventName || (IsEventPattern(eventName) && MatchEventPattern(eventName, name)) {
+			events = append(events, e)
+		}
+	}
+	ed.patternsMu.RUnlock()
+	for _, e := range events {
+		for _, cb := range callbacks {
+			if err = callCallback(cb, e); err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// TriggerPluginsSticky triggers the event to plugins and remembers it: handlers registered later by
+// OnPlugin with this event name (or matching pattern) receives it for each plugin on registration.
+func (ped *PluginEventDispatcher) TriggerPluginsSticky(e EventInterface, plugins ...*Plugin) error {
+	if len(plugins) == 0 {
+		plugins = ped.GetPlugins()
+	}
+	ped.handlersMu.Lock()
+	if ped.stickyPlugins == nil {
+		ped.stickyPlugins = map[string]*stickyPluginsEvent{}
+	}
+	ped.stickyPlugins[e.Name()] = &stickyPluginsEvent{e, append([]*Plugin{}, plugins...)}
+	ped.handlersMu.Unlock()
+	return ped.PluginDispatcher().TriggerPlugins(e, plugins...)
+}
+
+// addStickyPlugin adds the plugin into the sticky event plugins, used for late added plugins.
+func (ped *PluginEventDispatcher) addStickyPlugin(eventName string, p *Plugin) {
+	ped.handlersMu.Lock()
+	defer ped.handlersMu.Unlock()
+	if s, ok := ped.stickyPlugins[eventName]; ok {
+		s.plugins = append(s.plugins, p)
+	}
+}
+
+func (ped *PluginEventDispatcher) replaySticky(eventName string, h *pluginHandler) (err error) {
+	ped.handlersMu.RLock()
+	var events []*stickyPluginsEvent
+	for name, s := range ped.stickyPlugins {
+		if name == eventName || (IsEventPattern(eventName) && MatchEventPattern(eventName, name)) {
+			events = append(events, s)
+		}
+	}
+	ped.handlersMu.RUnlock()
+
+	dis := ped.PluginDispatcher()
+	for _, s := range events {
+		for _, p := range s.plugins {
+			e := &PluginEvent{
+				EventInterface: &Event{PName: "plugin:" + s.e.Name()},
+				plugin:         p,
+				options:        dis.Options(),
+				dispatcher:     dis,
+				parent:         s.e,
+			}
+			if err = ped.callHandler(eventName, h, e); err != nil {
+				return
+			}
+			if err = e.Error(); err != nil {
+				return
+			}
+		}
+	}
+	return
+}
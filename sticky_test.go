@@ -0,0 +1,114 @@
+package pluggable
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+type stickyPluginA struct{}
+type stickyPluginB struct{}
+type stickyPluginLate struct{}
+
+type stickyRecorder struct {
+	mu   sync.Mutex
+	uids []string
+}
+
+func (r *stickyRecorder) record(e PluginEventInterface) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.uids = append(r.uids, e.Plugin().UID())
+}
+
+func (r *stickyRecorder) has(uid string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, u := range r.uids {
+		if u == uid {
+			return true
+		}
+	}
+	return false
+}
+
+func newStickyPlugins(t *testing.T) *Plugins {
+	pls := NewPlugins()
+	if err := pls.Add(&stickyPluginA{}, &stickyPluginB{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := pls.Init(); err != nil {
+		t.Fatal(err)
+	}
+	return pls
+}
+
+func TestStickyLateHandler(t *testing.T) {
+	pls := newStickyPlugins(t)
+	var r stickyRecorder
+	pls.OnPlugin(E_POST_INIT, r.record)
+	for _, uid := range UIDs(&stickyPluginA{}, &stickyPluginB{}) {
+		if !r.has(uid) {
+			t.Errorf("postInit is not replayed for %s", uid)
+		}
+	}
+
+	var initDone int
+	if err := pls.OnE(E_INIT_DONE, func(e EventInterface) {
+		initDone++
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if initDone != 1 {
+		t.Errorf("initDone replays = %d, want 1", initDone)
+	}
+}
+
+func TestStickyLatePlugin(t *testing.T) {
+	pls := newStickyPlugins(t)
+	var before, after stickyRecorder
+	pls.OnPlugin(E_POST_INIT, before.record)
+	if err := pls.Add(&stickyPluginLate{}); err != nil {
+		t.Fatal(err)
+	}
+	pls.OnPlugin(E_POST_INIT, after.record)
+
+	uid := UID(&stickyPluginLate{})
+	if !before.has(uid) {
+		t.Error("postInit is not triggered for the late plugin")
+	}
+	if !after.has(uid) {
+		t.Error("postInit is not replayed for the late plugin")
+	}
+}
+
+func TestStickyReplayError(t *testing.T) {
+	pls := newStickyPlugins(t)
+	fail := errors.New("fail")
+
+	var calls int
+	sub, err := pls.OnPluginWithOptionsE(E_POST_INIT, func(e PluginEventInterface) error {
+		calls++
+		return fail
+	})
+	if !errors.Is(err, fail) || sub != nil {
+		t.Fatalf("OnPluginWithOptionsE = %v, %v, want nil, %v", sub, err, fail)
+	}
+	calls = 0
+	pls.TriggerPlugins(NewEvent(E_POST_INIT))
+	if calls != 0 {
+		t.Errorf("the handler of failed replay is called %d times", calls)
+	}
+
+	if err = pls.OnE(E_INIT_DONE, func(e EventInterface) error {
+		calls++
+		return fail
+	}); !errors.Is(err, fail) {
+		t.Fatalf("OnE = %v, want %v", err, fail)
+	}
+	calls = 0
+	pls.Trigger(NewEvent(E_INIT_DONE))
+	if calls != 0 {
+		t.Errorf("the callback of failed replay is called %d times", calls)
+	}
+}
@@ -0,0 +1,85 @@
+package pluggable
+
+import (
+	"context"
+	"fmt"
+)
+
+// TypedEvent is the event of EventType with payload T.
+type TypedEvent[T any] struct {
+	PluginEventInterface
+	Context context.Context
+	Payload T
+}
+
+// EventType is the typed event definition named Name, bound to dispatcher.
+type EventType[T any] struct {
+	Name string
+	dis  PluginEventDispatcherInterface
+}
+
+func NewEventType[T any](dis PluginEventDispatcherInterface, name string) *EventType[T] {
+	return &EventType[T]{name, dis}
+}
+
+func (et *EventType[T]) New(ctx context.Context, payload T) *TypedEvent[T] {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return &TypedEvent[T]{NewPluginEvent(et.Name), ctx, payload}
+}
+
+func (et *EventType[T]) typed(e EventInterface) (te *TypedEvent[T], err error) {
+	var ok bool
+	if te, ok = EventAs[*TypedEvent[T]](e); !ok {
+		err = eventTypeMismatch(et.Name, e, te)
+	}
+	return
+}
+
+// eventTypeMismatch returns the error of event name received with type of e, instead of the type
+// of expected.
+func eventTypeMismatch(name string, e, expected interface{}) error {
+	return fmt.Errorf("Event %q: type %T, expected %T", name, e, expected)
+}
+
+func (et *EventType[T]) callback(cb func(ctx context.Context, payload T) error) func(e PluginEventInterface) error {
+	return func(e PluginEventInterface) error {
+		te, err := et.typed(e)
+		if err != nil {
+			return err
+		}
+		return cb(te.Context, te.Payload)
+	}
+}
+
+// Emit triggers the event on dispatcher.
+func (et *EventType[T]) Emit(ctx context.Context, payload T) error {
+	return et.dis.Trigger(et.New(ctx, payload))
+}
+
+// EmitPlugins triggers the event to plugins (see TriggerPlugins).
+func (et *EventType[T]) EmitPlugins(ctx context.Context, payload T, plugins ...*Plugin) error {
+	return et.dis.TriggerPlugins(et.New(ctx, payload), plugins...)
+}
+
+// On registers the callback on dispatcher.
+func (et *EventType[T]) On(cb func(ctx context.Context, payload T) error) error {
+	return et.OnTo(et.dis, cb)
+}
+
+// OnTo registers the callback on other dispatcher, like the plugin dispatcher.
+func (et *EventType[T]) OnTo(dis EventDispatcherInterface, cb func(ctx context.Context, payload T) error) error {
+	return dis.OnE(et.Name, et.callback(cb))
+}
+
+// OnPlugin registers the plugin event callback (see OnPluginWithOptions).
+func (et *EventType[T]) OnPlugin(cb func(ctx context.Context, p *Plugin, payload T) error, opt ...HandlerOption) (*Subscription, error) {
+	return et.dis.OnPluginWithOptionsE(et.Name, func(e PluginEventInterface) error {
+		te, err := et.typed(e)
+		if err != nil {
+			return err
+		}
+		return cb(te.Context, e.Plugin(), te.Payload)
+	}, opt...)
+}
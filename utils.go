@@ -1,7 +1,10 @@
 package pluggable
 
 import (
+	"bytes"
 	"reflect"
+	"runtime"
+	"strconv"
 
 	path_helpers "github.com/moisespsena-go/path-helpers"
 )
@@ -33,7 +36,7 @@ func IsOptionsProvider(v interface{}) bool {
 		v = plugin.Value
 	}
 	switch v.(type) {
-	case OptionProvider, OptionProviderE:
+	case OptionProvider, OptionProviderE, LazyOptionProvider:
 		return true
 	default:
 		return false
@@ -66,3 +69,15 @@ func Dispatcher(options *Options) PluginEventDispatcherInterface {
 }
 
 var Dis = Dispatcher
+
+// goroutineID returns the ID of current goroutine, parsed from their stack header
+// "goroutine N [...]".
+func goroutineID() (id uint64) {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i > 0 {
+		id, _ = strconv.ParseUint(string(b[:i]), 10, 64)
+	}
+	return
+}